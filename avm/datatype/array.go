@@ -6,7 +6,8 @@ import (
 )
 
 type Array struct {
-	items []StackItem
+	items    []StackItem
+	isStruct bool
 }
 
 func NewArray(value []StackItem) *Array{
@@ -15,6 +16,18 @@ func NewArray(value []StackItem) *Array{
 	return &a
 }
 
+// NewStruct creates the array pushed by NEWSTRUCT, it behaves as an array but
+// is serialized with the struct type.
+func NewStruct(value []StackItem) *Array {
+	a := NewArray(value)
+	a.isStruct = true
+	return a
+}
+
+func (a *Array) IsStruct() bool {
+	return a.isStruct
+}
+
 func (a *Array) Equals(other StackItem) bool{
 	if _, ok := other.(*Array); !ok {
 		return false
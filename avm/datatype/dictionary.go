@@ -64,6 +64,7 @@ func (dic *Dictionary) GetMap() map[StackItem]StackItem {
 }
 
 func (dic *Dictionary) PutStackItem(key, value StackItem)  {
+	dic.Remove(key)
 	dic.dic[key] = value
 }
 
@@ -15,6 +15,9 @@ type ExecutionContext struct {
 	InstructionPointer int
 	CodeHash           []byte
 	GetPriceOnly       bool
+	// Snapshot is set if the context was loaded by a contract call and owns
+	// a state snapshot.
+	Snapshot bool
 }
 
 func NewExecutionContext(script []byte, pushOnly bool, breakPoints []uint) *ExecutionContext {
@@ -15,7 +15,7 @@ import (
 const (
 	MAXSTEPS                      = -1
 	ratio                         = 100000
-	gasFree                       = 10 * 100000000
+	verificationGas               = 10 * 100000000
 	StackLimit             uint32 = 2 * 1024
 	MaxItemSize            uint32 = 1024 * 1024
 	MaxArraySize           uint32 = 1024
@@ -27,6 +27,15 @@ const (
 	MAX_BIGINTEGER                = 32
 )
 
+// FreeGas is given to every application execution on top of the gas it is
+// created with, verifications always run with verificationGas.
+var FreeGas common.Fixed64 = 10 * 100000000
+
+// VMForkHeight is the first block executed with the forked opcode semantics,
+// map keys are deduplicated, NEWSTRUCT pushes a struct and XTUCK inserts a
+// single item from it on.
+var VMForkHeight uint32 = math.MaxUint32
+
 func NewExecutionEngine(container interfaces.IDataContainer, crypto interfaces.ICrypto, maxSteps int,
 	table interfaces.IScriptTable, service IGeneralService, gas common.Fixed64, trigger TriggerType,
 	testMode bool) *ExecutionEngine {
@@ -48,10 +57,20 @@ func NewExecutionEngine(container interfaces.IDataContainer, crypto interfaces.I
 	engine.service = NewGeneralService()
 	if service != nil {
 		engine.service.MergeMap(service.GetServiceMap())
+		if snapshots, ok := service.(ISnapshotService); ok {
+			engine.snapshots = snapshots
+		}
+	}
+	engine.forked = VMForkHeight == 0
+	if heights, ok := service.(IHeightService); ok {
+		engine.forked = heights.ExecutingHeight() >= VMForkHeight
 	}
 
 	engine.trigger = trigger
-	engine.gas = gas.IntValue() + gasFree
+	engine.gas = gas.IntValue() + FreeGas.IntValue()
+	if trigger == Verification {
+		engine.gas = gas.IntValue() + verificationGas
+	}
 	engine.gasConsumed = 0
 	engine.testMode = testMode
 
@@ -63,6 +82,8 @@ type ExecutionEngine struct {
 	table   interfaces.IScriptTable
 	service *GeneralService
 
+	snapshots ISnapshotService
+
 	dataContainer   interfaces.IDataContainer
 	invocationStack *utils.RandomAccessStack
 	opCount         int
@@ -81,12 +102,18 @@ type ExecutionEngine struct {
 	gasConsumed int64
 	trigger     TriggerType
 	testMode    bool
+	forked      bool
 }
 
 func (e *ExecutionEngine) IsTestMode() bool {
 	return e.testMode
 }
 
+// IsForked returns whether the engine executes at or above VMForkHeight.
+func (e *ExecutionEngine) IsForked() bool {
+	return e.forked
+}
+
 func (e *ExecutionEngine) GetGasConsumed() int64 {
 	return e.gasConsumed
 }
@@ -185,12 +212,45 @@ func (e *ExecutionEngine) Execute() error {
 		err := e.StepInto()
 		if err != nil {
 			log.Error("ExecutionEngine on avm:", err.Error())
+			e.dropSnapshots()
 			return err
 		}
 	}
+	if e.state&FAULT == FAULT {
+		e.dropSnapshots()
+	}
 	return nil
 }
 
+// loadCallScript loads the script of a called contract in a new state
+// snapshot. A tail call takes over the snapshot of the context it replaces.
+func (e *ExecutionEngine) loadCallScript(script []byte, tail bool) {
+	snapshot := false
+	if tail {
+		context := AssertExecutionContext(e.invocationStack.Pop())
+		snapshot = context != nil && context.Snapshot
+	}
+	if !snapshot && e.snapshots != nil {
+		e.snapshots.PushSnapshot()
+	}
+	e.LoadScript(script, false).Snapshot = e.snapshots != nil
+}
+
+// dropSnapshots discards the snapshots of the contexts left on the
+// invocation stack when the execution faults.
+func (e *ExecutionEngine) dropSnapshots() {
+	if e.snapshots == nil {
+		return
+	}
+	for i := 0; i < e.invocationStack.Count(); i++ {
+		context := AssertExecutionContext(e.invocationStack.Peek(i))
+		if context != nil && context.Snapshot {
+			context.Snapshot = false
+			e.snapshots.PopSnapshot(false)
+		}
+	}
+}
+
 func (e *ExecutionEngine) StepInto() error {
 	if e.invocationStack.Count() == 0 {
 		e.state = VMState(e.state | HALT)
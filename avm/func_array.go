@@ -96,7 +96,11 @@ func opSetItem(e *ExecutionEngine) (VMState, error) {
 		items := itemArr.GetArray()
 		items[index.Int64()] = newItem
 	} else if _,ok := itemArr.(*datatype.Dictionary); ok {
-		itemArr.(*datatype.Dictionary).PutStackItem(key, newItem)
+		if e.IsForked() {
+			itemArr.(*datatype.Dictionary).PutStackItem(key, newItem)
+		} else {
+			itemArr.GetMap()[key] = newItem
+		}
 	} else {
 		items := itemArr.GetByteArray()
 		index := key.GetBigInteger()
@@ -115,6 +119,19 @@ func opNewArray(e *ExecutionEngine) (VMState, error) {
 	return NONE, nil
 }
 
+func opNewStruct(e *ExecutionEngine) (VMState, error) {
+	if !e.IsForked() {
+		return opNewArray(e)
+	}
+	count := PopInt(e)
+	items := NewStackItems()
+	for i := 0; i < count; i++ {
+		items = append(items, datatype.NewBoolean(false))
+	}
+	PushData(e, datatype.NewStruct(items))
+	return NONE, nil
+}
+
 func opAppend(e *ExecutionEngine) (VMState, error) {
 	newItem := PopStackItem(e)
 	itemArr := PopStackItem(e)
@@ -43,7 +43,10 @@ func opCall(e *ExecutionEngine) (VMState, error) {
 }
 
 func opRet(e *ExecutionEngine) (VMState, error) {
-	e.invocationStack.Pop()
+	context := AssertExecutionContext(e.invocationStack.Pop())
+	if context != nil && context.Snapshot && e.snapshots != nil {
+		e.snapshots.PopSnapshot(true)
+	}
 	return NONE, nil
 }
 
@@ -57,10 +60,7 @@ func opAppCall(e *ExecutionEngine) (VMState, error) {
 	if script == nil {
 		return FAULT, errors.ErrNotFindScript
 	}
-	if e.opCode == TAILCALL {
-		e.invocationStack.Pop()
-	}
-	e.LoadScript(script, false)
+	e.loadCallScript(script, e.opCode == TAILCALL)
 	return NONE, nil
 }
 
@@ -132,11 +132,7 @@ func opCallE(e *ExecutionEngine) (VMState, error) {
 	if script == nil {
 		return FAULT, err
 	}
-	if (e.opCode == CALL_ET || e.opCode == CALL_EDT) {
-		e.invocationStack.Pop()
-	}
-
-	e.LoadScript(script, false)
+	e.loadCallScript(script, e.opCode == CALL_ET || e.opCode == CALL_EDT)
 
 	return NONE, nil
 }
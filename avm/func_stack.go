@@ -55,6 +55,14 @@ func opXTuck(e *ExecutionEngine) (VMState, error) {
 	if n < 0 || n > e.evaluationStack.Count()-1 {
 		return FAULT, nil
 	}
+	if n > 0 && !e.IsForked() {
+		// below VMForkHeight the items under index n are inserted as a
+		// single slice, the way RandomAccessStack.Insert used to.
+		index := e.evaluationStack.Count() - n
+		element := []interface{}{e.evaluationStack.Element[:index], e.evaluationStack.Peek(0)}
+		e.evaluationStack.Element = append(element, e.evaluationStack.Element[index:]...)
+		return NONE, nil
+	}
 	e.evaluationStack.Insert(n, e.evaluationStack.Peek(0))
 	return NONE, nil
 }
@@ -0,0 +1,33 @@
+package avm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpXTuck(t *testing.T) {
+	xtuck := func(forked bool) *ExecutionEngine {
+		e := NewExecutionEngine(nil, nil, MAXSTEPS, nil, nil, 0, Application, false)
+		e.forked = forked
+		for i := 1; i <= 3; i++ {
+			pushData(e, i)
+		}
+		pushData(e, 2)
+		state, err := opXTuck(e)
+		assert.NoError(t, err)
+		assert.Equal(t, NONE, state)
+		return e
+	}
+
+	e := xtuck(true)
+	assert.Equal(t, 4, e.evaluationStack.Count())
+	for _, item := range []int{3, 2, 3, 1} {
+		assert.Equal(t, item, PopInt(e))
+	}
+
+	// below the fork height the items under the index are inserted as one
+	e = xtuck(false)
+	assert.Equal(t, 4, e.evaluationStack.Count())
+	assert.Nil(t, AssertStackItem(e.evaluationStack.Peek(3)))
+}
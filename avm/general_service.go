@@ -10,6 +10,19 @@ type IGeneralService interface {
 	GetServiceMap() map[string]func(*ExecutionEngine) bool
 }
 
+// ISnapshotService is implemented by services keeping a state snapshot for
+// every contract call, the snapshot is committed when the call returns.
+type ISnapshotService interface {
+	PushSnapshot()
+	PopSnapshot(commit bool)
+}
+
+// IHeightService is implemented by services which know the height of the
+// block the execution belongs to.
+type IHeightService interface {
+	ExecutingHeight() uint32
+}
+
 type GeneralService struct {
 	dictionary map[string]func(*ExecutionEngine) bool
 	methods    map[uint32]func(*ExecutionEngine) bool
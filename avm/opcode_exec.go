@@ -136,7 +136,7 @@ var (
 		CALL_ET:  {CALL_ET, "CALL_E", opCallE, validateInvocationStack},
 		CALL_EDT: {CALL_EDT, "CALL_E", opCallE, validateInvocationStack},
 
-		NEWSTRUCT: {NEWSTRUCT, "NEWSTRUCT", opNewArray, validateNewArray},
+		NEWSTRUCT: {NEWSTRUCT, "NEWSTRUCT", opNewStruct, validateNewArray},
 		//Map
 		NEWMAP: {NEWMAP, "NEWMAP", opNewMap, nil},
 		APPEND: {APPEND, "APPEND", opAppend, validateAppend},
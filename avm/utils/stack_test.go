@@ -77,7 +77,7 @@ func TestRandomAccessStack_Swap(t *testing.T) {
 	for i := 0; i < stack.Count(); i++ {
 		fmt.Print(stack.Peek(i))
 	}
-	fmt.Println("\n")
+	fmt.Print("\n\n")
 	stack.Swap(1,5)
 
 	for i := 0; i < stack.Count(); i++ {
@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"bytes"
+	"sort"
 
 	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
 	"github.com/elastos/Elastos.ELA.SideChain/database"
@@ -11,9 +12,16 @@ import (
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/enumerators"
 )
 
+// writer is implemented by both database.Database and database.Batch.
+type writer interface {
+	Put(key []byte, value []byte) error
+	Delete(key []byte) error
+}
+
 type DBCache struct {
-	RWSet *storage.RWSet
-	db    database.Database
+	RWSet  *storage.RWSet
+	db     database.Database
+	parent *DBCache
 }
 
 func NewDBCache(db database.Database) *DBCache {
@@ -23,24 +31,72 @@ func NewDBCache(db database.Database) *DBCache {
 	}
 }
 
+// NewChildDBCache creates a cache reading through parent, its writes are
+// merged into parent on Commit.
+func NewChildDBCache(parent *DBCache) *DBCache {
+	return &DBCache{
+		RWSet:  storage.NewRWSet(),
+		db:     parent.db,
+		parent: parent,
+	}
+}
+
+// Commit merges the writes into the parent cache, or writes them to the
+// database if the cache has no parent.
 func (cache *DBCache) Commit() {
-	rwSet := cache.RWSet.WriteSet
-	for k, v := range rwSet {
+	if cache.parent != nil {
+		for k, v := range cache.RWSet.WriteSet {
+			cache.parent.RWSet.WriteSet[k] = v
+		}
+		cache.RWSet = storage.NewRWSet()
+		return
+	}
+	cache.write(cache.db)
+}
+
+// CommitTo puts the writes into batch, so they are persisted atomically with
+// the rest of the batch.
+func (cache *DBCache) CommitTo(batch database.Batch) {
+	cache.write(batch)
+	cache.RWSet = storage.NewRWSet()
+}
+
+func (cache *DBCache) write(db writer) {
+	for _, v := range cache.SortedWrites() {
 		key := make([]byte, 0)
-		key = append([]byte{byte(v.Prefix)}, []byte(k)...)
+		key = append([]byte{byte(v.Prefix)}, []byte(v.Key)...)
 		if v.IsDeleted {
-			cache.db.Delete(key)
+			db.Delete(key)
 		} else {
 			b := new(bytes.Buffer)
 			v.Item.Serialize(b)
 			value := make([]byte, 0)
 			value = append(value, b.Bytes()...)
-			cache.db.Put(key, value)
+			db.Put(key, value)
 		}
 	}
 }
 
+// SortedWrites returns the writes ordered by prefix and key, so they are
+// applied and hashed in the same order on every node.
+func (cache *DBCache) SortedWrites() []*storage.Write {
+	writes := make([]*storage.Write, 0, len(cache.RWSet.WriteSet))
+	for _, v := range cache.RWSet.WriteSet {
+		writes = append(writes, v)
+	}
+	sort.Slice(writes, func(i, j int) bool {
+		if writes[i].Prefix != writes[j].Prefix {
+			return writes[i].Prefix < writes[j].Prefix
+		}
+		return writes[i].Key < writes[j].Key
+	})
+	return writes
+}
+
 func (cache *DBCache) TryGetInternal(prefix blockchain.EntryPrefix, key string) (states.IStateValueInterface, error) {
+	if cache.parent != nil {
+		return cache.parent.TryGet(prefix, key)
+	}
 	k := make([]byte, 0)
 	k = append([]byte{byte(prefix)}, []byte(key)...)
 	value, err := cache.db.Get(k)
@@ -50,11 +106,11 @@ func (cache *DBCache) TryGetInternal(prefix blockchain.EntryPrefix, key string)
 	return states.GetStateValue(prefix, value)
 }
 
+// TryDelete marks the key deleted in the write set, the deletion reaches the
+// database when the cache is committed.
 func (cache *DBCache) TryDelete(prefix blockchain.EntryPrefix, key string) bool {
-	k := make([]byte, 0)
-	k = append([]byte{byte(prefix)}, []byte(key)...)
-	err := cache.db.Delete(k)
-	return err == nil
+	cache.RWSet.Delete(prefix, key)
+	return true
 }
 
 func (cache *DBCache) GetOrAdd(prefix blockchain.EntryPrefix, key string, value states.IStateValueInterface) (states.IStateValueInterface, error) {
@@ -98,22 +154,15 @@ func (cache *DBCache) GetChainStoreDb() database.Database {
 	return cache.db
 }
 
+// FindInternal lists the entries under prefix and keyPrefix, the writes of the
+// cache are applied on top of the entries of the parent cache or the database.
 func (cache *DBCache) FindInternal(prefix blockchain.EntryPrefix, keyPrefix string) database.Iterator {
-	k := make([]byte, 0)
-	k = append([]byte{byte(prefix)}, []byte(keyPrefix)...)
-	count := len(k)
-	iteratorList := enumerators.NewListIterator()
-	for key, v := range cache.RWSet.WriteSet {
-		kvalue := key[0 : count - 1]
-		if kvalue == keyPrefix {
-			ky := append([]byte{byte(prefix)}, []byte(key)...)
-			iteratorList.Add(ky, v.Item)
-		}
-	}
-	if iteratorList.HashNext() {
-		return iteratorList
+	var iter database.Iterator
+	if cache.parent != nil {
+		iter = cache.parent.FindInternal(prefix, keyPrefix)
+	} else {
+		k := append([]byte{byte(prefix)}, []byte(keyPrefix)...)
+		iter = enumerators.NewIterator(cache.db.NewIterator(k))
 	}
-
-	iter := cache.db.NewIterator(k)
-	return enumerators.NewIterator(iter)
-}
\ No newline at end of file
+	return storage.MergeWrites(cache.RWSet, prefix, keyPrefix, iter)
+}
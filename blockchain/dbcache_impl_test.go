@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/enumerators"
+)
+
+type memDB struct {
+	database.Database
+	data map[string][]byte
+}
+
+func (db *memDB) Get(key []byte) ([]byte, error) {
+	if value, ok := db.data[string(key)]; ok {
+		return value, nil
+	}
+	return nil, errors.New("leveldb: not found")
+}
+
+func (db *memDB) NewIterator(prefix []byte) database.Iterator {
+	keys := make([]string, 0, len(db.data))
+	for key := range db.data {
+		if strings.HasPrefix(key, string(prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	iter := enumerators.NewListIterator()
+	for _, key := range keys {
+		iter.Add([]byte(key), db.data[key])
+	}
+	return iter
+}
+
+func findKeys(iter database.Iterator) []string {
+	keys := make([]string, 0)
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()[1:]))
+	}
+	return keys
+}
+
+func TestDBCache_FindInternal(t *testing.T) {
+	contractKey := strings.Repeat("c", 20)
+	keyPrefix := strings.Repeat("s", 21) + "key"
+	storageKey := func(key string) string {
+		return string([]byte{byte(blockchain.ST_Storage)}) + key
+	}
+	db := &memDB{data: map[string][]byte{
+		storageKey(keyPrefix + "1"): {1},
+		storageKey(keyPrefix + "2"): {2},
+		storageKey("other"):         {3},
+	}}
+
+	// a contract deployed earlier in the block leaves a key shorter than the
+	// storage keys in the block cache
+	block := NewDBCache(db)
+	block.RWSet.Add(blockchain.ST_Contract, contractKey, states.NewContractState())
+	block.RWSet.Delete(blockchain.ST_Storage, keyPrefix+"2")
+
+	tx := NewChildDBCache(block)
+	tx.RWSet.Add(blockchain.ST_Storage, keyPrefix+"3", states.NewStorageItem([]byte{4}))
+	assert.Equal(t, []string{keyPrefix + "1", keyPrefix + "3"},
+		findKeys(tx.FindInternal(blockchain.ST_Storage, keyPrefix)))
+
+	// the writes of the transaction replace the entries below them
+	tx.RWSet.Add(blockchain.ST_Storage, keyPrefix+"2", states.NewStorageItem([]byte{5}))
+	iter := tx.FindInternal(blockchain.ST_Storage, keyPrefix)
+	assert.Equal(t, []string{keyPrefix + "1", keyPrefix + "2", keyPrefix + "3"}, findKeys(iter))
+	assert.True(t, iter.Seek([]byte(storageKey(keyPrefix + "2"))))
+	assert.Equal(t, []byte{5}, iter.Value())
+
+	assert.Equal(t, []string{contractKey}, findKeys(tx.FindInternal(blockchain.ST_Contract, "")))
+}
@@ -0,0 +1,72 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/storage"
+)
+
+func sha256d(data []byte) common.Uint256 {
+	first := sha256.Sum256(data)
+	return common.Uint256(sha256.Sum256(first[:]))
+}
+
+// WriteHash hashes the prefix, key and value of a write. A deletion is hashed
+// with an empty value and a different flag than a put.
+func WriteHash(write *storage.Write) common.Uint256 {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(write.Prefix))
+	common.WriteVarString(buf, write.Key)
+	if write.IsDeleted {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+		value := new(bytes.Buffer)
+		write.Item.Serialize(value)
+		common.WriteVarBytes(buf, value.Bytes())
+	}
+	return sha256d(buf.Bytes())
+}
+
+// ComputeMerkleRoot computes the root of a binary merkle tree over hashes.
+// Inner nodes are hashed with a leading 0x01 byte so they can not be taken for
+// a leaf, and the last hash of an odd level is carried up unpaired, so a list
+// and the list with its last hash repeated have different roots.
+func ComputeMerkleRoot(hashes []common.Uint256) common.Uint256 {
+	if len(hashes) == 0 {
+		return common.Uint256{}
+	}
+	level := hashes
+	for len(level) > 1 {
+		next := make([]common.Uint256, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			node := append([]byte{1}, level[i][:]...)
+			next = append(next, sha256d(append(node, level[i+1][:]...)))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// ChangesRoot is the merkle root of the sorted writes of the cache.
+func (cache *DBCache) ChangesRoot() common.Uint256 {
+	writes := cache.SortedWrites()
+	hashes := make([]common.Uint256, 0, len(writes))
+	for _, write := range writes {
+		hashes = append(hashes, WriteHash(write))
+	}
+	return ComputeMerkleRoot(hashes)
+}
+
+// ComputeStateRoot chains the changes root of a block to the state root of
+// the previous block, so equal state roots mean equal contract state history.
+func ComputeStateRoot(prevRoot common.Uint256, changesRoot common.Uint256) common.Uint256 {
+	return sha256d(append(prevRoot[:], changesRoot[:]...))
+}
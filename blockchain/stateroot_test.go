@@ -0,0 +1,46 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+func TestChangesRoot(t *testing.T) {
+	cache1 := NewDBCache(nil)
+	cache1.RWSet.Add(blockchain.ST_Storage, "b", states.NewStorageItem([]byte{2}))
+	cache1.RWSet.Add(blockchain.ST_Storage, "a", states.NewStorageItem([]byte{1}))
+	cache1.RWSet.Delete(blockchain.ST_Storage, "c")
+
+	cache2 := NewDBCache(nil)
+	cache2.RWSet.Delete(blockchain.ST_Storage, "c")
+	cache2.RWSet.Add(blockchain.ST_Storage, "a", states.NewStorageItem([]byte{1}))
+	cache2.RWSet.Add(blockchain.ST_Storage, "b", states.NewStorageItem([]byte{2}))
+
+	assert.Equal(t, cache1.ChangesRoot(), cache2.ChangesRoot())
+	writes := cache1.SortedWrites()
+	assert.Equal(t, "a", writes[0].Key)
+	assert.Equal(t, "b", writes[1].Key)
+	assert.Equal(t, "c", writes[2].Key)
+
+	cache2.RWSet.WriteSet["b"].Item = states.NewStorageItem([]byte{3})
+	assert.NotEqual(t, cache1.ChangesRoot(), cache2.ChangesRoot())
+}
+
+func TestComputeMerkleRoot(t *testing.T) {
+	assert.Equal(t, common.Uint256{}, ComputeMerkleRoot(nil))
+
+	hash := common.Uint256{1}
+	assert.Equal(t, hash, ComputeMerkleRoot([]common.Uint256{hash}))
+
+	hashes := []common.Uint256{{1}, {2}, {3}}
+	root := ComputeMerkleRoot(hashes)
+	assert.NotEqual(t, root, ComputeMerkleRoot([]common.Uint256{{1}, {2}, {3}, {3}}))
+	assert.NotEqual(t, root, ComputeMerkleRoot([]common.Uint256{{1}, {2}}))
+	assert.NotEqual(t, root, ComputeMerkleRoot([]common.Uint256{{2}, {1}, {3}}))
+}
@@ -27,6 +27,12 @@ var (
 	// Set default active net params.
 	activeNetParams = &params.MainNetParams
 
+	// Set default active gas config.
+	activeGasConfig = &params.MainNetGasConfig
+
+	// Set default active fork config.
+	activeForkConfig = &params.MainNetForkConfig
+
 	// Load configuration from file.
 	cfg, loadConfigErr = loadNewConfig()
 )
@@ -43,6 +49,7 @@ type config struct {
 		HttpRestPort               uint16
 		HttpJsonPort               uint16
 		HttpWsPort                 uint16
+		HttpWsSubscribePort        uint16
 		NodePort                   uint16
 		PrintLevel                 elalog.Level
 		MaxLogsSize                int64
@@ -51,6 +58,11 @@ type config struct {
 		DisableTxFilters           bool
 		PrintSyncState             bool
 		MainChainFoundationAddress string
+		GasPrice                   int64
+		GasFeeHeight               *uint32
+		VMForkHeight               *uint32
+		ManifestForkHeight         *uint32
+		RuntimeForkHeight          *uint32
 		PowConfiguration           struct {
 			PayToAddr    string
 			AutoMining   bool
@@ -62,18 +74,19 @@ type config struct {
 }
 
 type appConfig struct {
-	HttpRestPort      uint16
-	HttpJsonPort      uint16
-	HttpWsPort        uint16
-	Mining            bool
-	MinerInfo         string
-	MinerAddr         string
-	LogLevel          string
-	MaxLogsFolderSize int64
-	MaxPerLogFileSize int64
-	MonitorState      bool
-	PrintSyncState    bool
-	DataDir           string
+	HttpRestPort        uint16
+	HttpJsonPort        uint16
+	HttpWsPort          uint16
+	HttpWsSubscribePort uint16
+	Mining              bool
+	MinerInfo           string
+	MinerAddr           string
+	LogLevel            string
+	MaxLogsFolderSize   int64
+	MaxPerLogFileSize   int64
+	MonitorState        bool
+	PrintSyncState      bool
+	DataDir             string
 }
 
 func loadNewConfig() (*appConfig, error) {
@@ -109,6 +122,8 @@ func loadNewConfig() (*appConfig, error) {
 		//do nothing. default is main net
 	} else if cfg.NetType == "TestNet" {
 		activeNetParams = &params.TestNetParams
+		activeGasConfig = &params.TestNetGasConfig
+		activeForkConfig = &params.TestNetForkConfig
 		appCfg.HttpJsonPort = 10606
 		appCfg.HttpRestPort = 10604
 		appCfg.MinerAddr = "8ZNizBf4KhhPjeJRGpox6rPcHE5Np6tFx3"
@@ -123,6 +138,7 @@ func loadNewConfig() (*appConfig, error) {
 	appCfg.HttpRestPort = config.HttpRestPort
 	appCfg.HttpJsonPort = config.HttpJsonPort
 	appCfg.HttpWsPort = config.HttpWsPort
+	appCfg.HttpWsSubscribePort = config.HttpWsSubscribePort
 	appCfg.PrintSyncState = config.PrintSyncState
 	appCfg.Mining = powCfg.AutoMining
 	appCfg.MinerInfo = powCfg.MinerInfo
@@ -171,6 +187,22 @@ func loadNewConfig() (*appConfig, error) {
 		activeNetParams.SpvParams.Foundation = config.MainChainFoundationAddress
 	}
 
+	if config.GasPrice > 0 {
+		activeGasConfig.Price = common.Fixed64(config.GasPrice)
+	}
+	if config.GasFeeHeight != nil {
+		activeGasConfig.FeeHeight = *config.GasFeeHeight
+	}
+	if config.VMForkHeight != nil {
+		activeForkConfig.VMHeight = *config.VMForkHeight
+	}
+	if config.ManifestForkHeight != nil {
+		activeForkConfig.ManifestHeight = *config.ManifestForkHeight
+	}
+	if config.RuntimeForkHeight != nil {
+		activeForkConfig.RuntimeHeight = *config.RuntimeForkHeight
+	}
+
 	if powCfg.InstantBlock {
 		// generate block instantly
 		activeNetParams.PowLimitBits = 0x207fffff
@@ -0,0 +1,250 @@
+// Package artifact builds deploy transactions from the outputs of the NEO
+// compiler, the .avm bytecode and the .abi.json description of the contract.
+package artifact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+// Parameter is a parameter of a function or an event of an .abi.json.
+type Parameter struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Function is a function or an event of an .abi.json.
+type Function struct {
+	Name       string      `json:"name"`
+	Parameters []Parameter `json:"parameters"`
+	ReturnType string      `json:"returntype"`
+}
+
+// ABI is the content of an .abi.json. The hash is the script hash of the
+// .avm as the compiler prints it, the entry point is the function the
+// contract is invoked through.
+type ABI struct {
+	Hash       string     `json:"hash"`
+	EntryPoint string     `json:"entrypoint"`
+	Functions  []Function `json:"functions"`
+	Events     []Function `json:"events"`
+}
+
+// Metadata is the part of a deploy payload the compiler does not output.
+type Metadata struct {
+	Name        string
+	Version     string
+	Author      string
+	Email       string
+	Description string
+	ProgramHash common.Uint168
+	Gas         common.Fixed64
+	// Manifest attaches the abi as the manifest of the contract. Deploy
+	// payloads carrying a manifest are only valid from the manifest fork
+	// height, the payload without one is valid at any height.
+	Manifest bool
+}
+
+// Artifact is a compiled contract.
+type Artifact struct {
+	Code []byte
+	ABI  *ABI
+}
+
+func ParseABI(data []byte) (*ABI, error) {
+	abi := new(ABI)
+	if err := json.Unmarshal(data, abi); err != nil {
+		return nil, fmt.Errorf("invalid abi: %s", err)
+	}
+	return abi, nil
+}
+
+// Load reads an .avm and its .abi.json.
+func Load(avmFile, abiFile string) (*Artifact, error) {
+	code, err := ioutil.ReadFile(avmFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(abiFile)
+	if err != nil {
+		return nil, err
+	}
+	abi, err := ParseABI(data)
+	if err != nil {
+		return nil, err
+	}
+	return New(code, abi)
+}
+
+// New checks the abi describes the code, the hash of the abi if any must be
+// the hash of the code and the entry point must be one of the functions.
+func New(code []byte, abi *ABI) (*Artifact, error) {
+	codeHash, err := params.ToCodeHash(code)
+	if err != nil {
+		return nil, err
+	}
+	if abi.Hash != "" {
+		hash := strings.TrimPrefix(strings.ToLower(abi.Hash), "0x")
+		if hash != common.BytesToHexString(params.UInt168ToUInt160(codeHash)) {
+			return nil, fmt.Errorf("abi hash %s is not the hash of the code", abi.Hash)
+		}
+	}
+	artifact := &Artifact{Code: code, ABI: abi}
+	if artifact.entryPoint() == nil {
+		return nil, fmt.Errorf("abi has no entry point function %q", abi.EntryPoint)
+	}
+	return artifact, nil
+}
+
+func (a *Artifact) entryPoint() *Function {
+	for i := range a.ABI.Functions {
+		if a.ABI.Functions[i].Name == a.ABI.EntryPoint {
+			return &a.ABI.Functions[i]
+		}
+	}
+	return nil
+}
+
+// CodeHash returns the hash the contract is deployed under.
+func (a *Artifact) CodeHash() (*common.Uint168, error) {
+	return params.ToCodeHash(a.Code)
+}
+
+// ParameterType returns the type of a parameter name of an .abi.json, the
+// compiler calls an Object parameter Any.
+func ParameterType(name string) (contract.ContractParameterType, error) {
+	if name == "Any" {
+		return contract.Object, nil
+	}
+	t, ok := contract.ParameterTypeMap[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported parameter type %q", name)
+	}
+	return t, nil
+}
+
+// FunctionCode returns the code with the parameter types and the return type
+// of the entry point.
+func (a *Artifact) FunctionCode() (*types.FunctionCode, error) {
+	entry := a.entryPoint()
+	if entry == nil {
+		return nil, errors.New("abi has no entry point")
+	}
+	code := &types.FunctionCode{
+		Code:           a.Code,
+		ParameterTypes: make([]contract.ContractParameterType, 0, len(entry.Parameters)),
+	}
+	for _, parameter := range entry.Parameters {
+		t, err := ParameterType(parameter.Type)
+		if err != nil || t == contract.Void {
+			return nil, fmt.Errorf("entry point parameter %s: unsupported type %q", parameter.Name, parameter.Type)
+		}
+		code.ParameterTypes = append(code.ParameterTypes, t)
+	}
+	returnType, err := ParameterType(entry.ReturnType)
+	if err != nil {
+		return nil, fmt.Errorf("entry point: %s", err)
+	}
+	code.ReturnType = returnType
+	return code, nil
+}
+
+func manifestParameters(parameters []Parameter) ([]types.ManifestParameter, error) {
+	result := make([]types.ManifestParameter, 0, len(parameters))
+	for _, parameter := range parameters {
+		t, err := ParameterType(parameter.Type)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %s", parameter.Name, err)
+		}
+		result = append(result, types.ManifestParameter{Name: parameter.Name, Type: t})
+	}
+	return result, nil
+}
+
+// Manifest returns the manifest of the functions but the entry point and the
+// events of the abi.
+func (a *Artifact) Manifest() (*types.ContractManifest, error) {
+	manifest := new(types.ContractManifest)
+	for _, function := range a.ABI.Functions {
+		if function.Name == a.ABI.EntryPoint {
+			continue
+		}
+		parameters, err := manifestParameters(function.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("function %s: %s", function.Name, err)
+		}
+		returnType, err := ParameterType(function.ReturnType)
+		if err != nil {
+			return nil, fmt.Errorf("function %s: %s", function.Name, err)
+		}
+		manifest.Methods = append(manifest.Methods, types.ManifestMethod{
+			Name:       function.Name,
+			Parameters: parameters,
+			ReturnType: returnType,
+		})
+	}
+	for _, event := range a.ABI.Events {
+		parameters, err := manifestParameters(event.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %s", event.Name, err)
+		}
+		manifest.Events = append(manifest.Events, types.ManifestEvent{
+			Name:       event.Name,
+			Parameters: parameters,
+		})
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// DeployTransaction returns the unsigned transaction deploying the contract.
+// The inputs paying the fee and the programs are left to the wallet signing
+// it.
+func (a *Artifact) DeployTransaction(metadata *Metadata) (*side.Transaction, error) {
+	code, err := a.FunctionCode()
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Gas < 0 {
+		return nil, errors.New("invalid gas")
+	}
+	payload := &types.PayloadDeploy{
+		Code:        code,
+		Name:        metadata.Name,
+		CodeVersion: metadata.Version,
+		Author:      metadata.Author,
+		Email:       metadata.Email,
+		Description: metadata.Description,
+		ProgramHash: metadata.ProgramHash,
+		Gas:         metadata.Gas,
+	}
+	var version byte
+	if metadata.Manifest {
+		if payload.Manifest, err = a.Manifest(); err != nil {
+			return nil, err
+		}
+		version = types.DeployManifestPayloadVersion
+	}
+	return &side.Transaction{
+		TxType:         side.Deploy,
+		PayloadVersion: version,
+		Payload:        payload,
+		Attributes:     []*side.Attribute{},
+		Inputs:         []*side.Input{},
+		Outputs:        []*side.Output{},
+		Programs:       []*side.Program{},
+	}, nil
+}
@@ -0,0 +1,80 @@
+package artifact
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+// testdata/Token.avm is a compiled contract and Token.abi.json its abi, the
+// hash of the abi is the script hash of the code.
+func loadTestArtifact(t *testing.T) *Artifact {
+	artifact, err := Load("testdata/Token.avm", "testdata/Token.abi.json")
+	assert.NoError(t, err)
+	return artifact
+}
+
+func TestLoad(t *testing.T) {
+	artifact := loadTestArtifact(t)
+	codeHash, err := artifact.CodeHash()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xdb719204f274946034f04e8d2283c222d5e78e70", artifact.ABI.Hash)
+	assert.Equal(t, "db719204f274946034f04e8d2283c222d5e78e70",
+		common.BytesToHexString(params.UInt168ToUInt160(codeHash)))
+
+	// the abi of other code is rejected
+	code, err := ioutil.ReadFile("testdata/Token.avm")
+	assert.NoError(t, err)
+	code[len(code)-4] = byte(avm.PUSH1)
+	_, err = New(code, artifact.ABI)
+	assert.Error(t, err)
+}
+
+func TestArtifact_FunctionCode(t *testing.T) {
+	artifact := loadTestArtifact(t)
+	functionCode, err := artifact.FunctionCode()
+	assert.NoError(t, err)
+	assert.Equal(t, []contract.ContractParameterType{contract.String, contract.Array}, functionCode.ParameterTypes)
+	assert.Equal(t, contract.Object, functionCode.ReturnType)
+
+	manifest, err := artifact.Manifest()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(manifest.Methods))
+	assert.Equal(t, contract.Hash160, manifest.Method("balanceOf").Parameters[0].Type)
+	assert.Equal(t, contract.ContractParameterType(contract.InteropInterface),
+		manifest.Method("storageContext").ReturnType)
+	assert.Equal(t, 1, len(manifest.Events))
+
+	artifact.ABI.EntryPoint = "main"
+	_, err = New(artifact.Code, artifact.ABI)
+	assert.Error(t, err)
+}
+
+func TestArtifact_DeployTransaction(t *testing.T) {
+	artifact := loadTestArtifact(t)
+	tx, err := artifact.DeployTransaction(&Metadata{Name: "token", Version: "1.0", Gas: 100000000})
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0), tx.PayloadVersion)
+	assert.Nil(t, tx.Payload.(*types.PayloadDeploy).Manifest)
+
+	tx, err = artifact.DeployTransaction(&Metadata{Name: "token", Version: "1.0", Manifest: true})
+	assert.NoError(t, err)
+	assert.Equal(t, types.DeployManifestPayloadVersion, tx.PayloadVersion)
+	payload := tx.Payload.(*types.PayloadDeploy)
+	buf := new(bytes.Buffer)
+	assert.NoError(t, payload.Serialize(buf, tx.PayloadVersion))
+	payload2 := new(types.PayloadDeploy)
+	assert.NoError(t, payload2.Deserialize(bytes.NewReader(buf.Bytes()), tx.PayloadVersion))
+	assert.Equal(t, "token", payload2.Name)
+	assert.Equal(t, payload.Manifest.Bytes(), payload2.Manifest.Bytes())
+	assert.Equal(t, payload.Code.CodeHash(), payload2.Code.CodeHash())
+}
@@ -14,21 +14,32 @@ const (
 	String
 	Object
 	Hash168
-	Array = 0x10
-	Void  = 0xff
+	Array            = 0x10
+	InteropInterface = 0xf0
+	Void             = 0xff
 )
 
 var ParameterTypeMap = map[string]ContractParameterType{
-	"Signature": Signature,
-	"Boolean":   Boolean,
-	"Integer":   Integer,
-	"Hash160":   Hash160,
-	"Hash256":   Hash256,
-	"ByteArray": ByteArray,
-	"PublicKey": PublicKey,
-	"String":    String,
-	"Object":    Object,
-	"Hash168":   Hash168,
-	"Array":     Array,
-	"Void":      Void,
+	"Signature":        Signature,
+	"Boolean":          Boolean,
+	"Integer":          Integer,
+	"Hash160":          Hash160,
+	"Hash256":          Hash256,
+	"ByteArray":        ByteArray,
+	"PublicKey":        PublicKey,
+	"String":           String,
+	"Object":           Object,
+	"Hash168":          Hash168,
+	"Array":            Array,
+	"InteropInterface": InteropInterface,
+	"Void":             Void,
+}
+
+func (t ContractParameterType) String() string {
+	for name, value := range ParameterTypeMap {
+		if value == t {
+			return name
+		}
+	}
+	return "Unknown"
 }
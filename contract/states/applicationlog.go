@@ -0,0 +1,182 @@
+package states
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+// NotifyEvent is a Neo.Runtime.Notify raised by a contract, the state is the
+// serialized stack item passed to the syscall.
+type NotifyEvent struct {
+	CodeHash common.Uint168
+	State    []byte
+}
+
+func (event *NotifyEvent) Serialize(w io.Writer) error {
+	if err := event.CodeHash.Serialize(w); err != nil {
+		return err
+	}
+	return common.WriteVarBytes(w, event.State)
+}
+
+func (event *NotifyEvent) Deserialize(r io.Reader) error {
+	if err := event.CodeHash.Deserialize(r); err != nil {
+		return errors.New("NotifyEvent CodeHash Deserialize fail.")
+	}
+	state, err := common.ReadVarBytes(r, common.MaxVarStringLength, "NotifyEvent State")
+	if err != nil {
+		return errors.New("NotifyEvent State Deserialize fail.")
+	}
+	event.State = state
+	return nil
+}
+
+// LogEvent is a Neo.Runtime.Log raised by a contract.
+type LogEvent struct {
+	CodeHash common.Uint168
+	Message  string
+}
+
+func (event *LogEvent) Serialize(w io.Writer) error {
+	if err := event.CodeHash.Serialize(w); err != nil {
+		return err
+	}
+	return common.WriteVarString(w, event.Message)
+}
+
+func (event *LogEvent) Deserialize(r io.Reader) error {
+	if err := event.CodeHash.Deserialize(r); err != nil {
+		return errors.New("LogEvent CodeHash Deserialize fail.")
+	}
+	message, err := common.ReadVarString(r)
+	if err != nil {
+		return errors.New("LogEvent Message Deserialize fail.")
+	}
+	event.Message = message
+	return nil
+}
+
+// ApplicationLog is the receipt of a deploy or invoke transaction execution.
+type ApplicationLog struct {
+	StateBase
+	TxID          common.Uint256
+	VMState       byte
+	Fault         string
+	GasConsumed   common.Fixed64
+	Stack         [][]byte
+	Notifications []*NotifyEvent
+	Logs          []*LogEvent
+}
+
+func (log *ApplicationLog) Serialize(w io.Writer) error {
+	log.StateBase.Serialize(w)
+	if err := log.TxID.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteUint8(w, log.VMState); err != nil {
+		return err
+	}
+	if err := common.WriteVarString(w, log.Fault); err != nil {
+		return err
+	}
+	if err := log.GasConsumed.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteVarUint(w, uint64(len(log.Stack))); err != nil {
+		return err
+	}
+	for _, item := range log.Stack {
+		if err := common.WriteVarBytes(w, item); err != nil {
+			return err
+		}
+	}
+	if err := common.WriteVarUint(w, uint64(len(log.Notifications))); err != nil {
+		return err
+	}
+	for _, notification := range log.Notifications {
+		if err := notification.Serialize(w); err != nil {
+			return err
+		}
+	}
+	if err := common.WriteVarUint(w, uint64(len(log.Logs))); err != nil {
+		return err
+	}
+	for _, l := range log.Logs {
+		if err := l.Serialize(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (log *ApplicationLog) Deserialize(r io.Reader) error {
+	stateBase := new(StateBase)
+	if err := stateBase.Deserialize(r); err != nil {
+		return err
+	}
+	log.StateBase = *stateBase
+	if err := log.TxID.Deserialize(r); err != nil {
+		return errors.New("ApplicationLog TxID Deserialize fail.")
+	}
+	vmState, err := common.ReadUint8(r)
+	if err != nil {
+		return errors.New("ApplicationLog VMState Deserialize fail.")
+	}
+	log.VMState = vmState
+	log.Fault, err = common.ReadVarString(r)
+	if err != nil {
+		return errors.New("ApplicationLog Fault Deserialize fail.")
+	}
+	if err := log.GasConsumed.Deserialize(r); err != nil {
+		return errors.New("ApplicationLog GasConsumed Deserialize fail.")
+	}
+
+	count, err := common.ReadVarUint(r, 0)
+	if err != nil {
+		return errors.New("ApplicationLog Stack Deserialize fail.")
+	}
+	log.Stack = make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		item, err := common.ReadVarBytes(r, common.MaxVarStringLength, "ApplicationLog Stack")
+		if err != nil {
+			return errors.New("ApplicationLog Stack Deserialize fail.")
+		}
+		log.Stack = append(log.Stack, item)
+	}
+
+	count, err = common.ReadVarUint(r, 0)
+	if err != nil {
+		return errors.New("ApplicationLog Notifications Deserialize fail.")
+	}
+	log.Notifications = make([]*NotifyEvent, 0, count)
+	for i := uint64(0); i < count; i++ {
+		notification := new(NotifyEvent)
+		if err := notification.Deserialize(r); err != nil {
+			return err
+		}
+		log.Notifications = append(log.Notifications, notification)
+	}
+
+	count, err = common.ReadVarUint(r, 0)
+	if err != nil {
+		return errors.New("ApplicationLog Logs Deserialize fail.")
+	}
+	log.Logs = make([]*LogEvent, 0, count)
+	for i := uint64(0); i < count; i++ {
+		l := new(LogEvent)
+		if err := l.Deserialize(r); err != nil {
+			return err
+		}
+		log.Logs = append(log.Logs, l)
+	}
+	return nil
+}
+
+func (log *ApplicationLog) Bytes() []byte {
+	b := new(bytes.Buffer)
+	log.Serialize(b)
+	return b.Bytes()
+}
@@ -0,0 +1,30 @@
+package states
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+func TestApplicationLog(t *testing.T) {
+	appLog := ApplicationLog{}
+	appLog.TxID = common.Uint256{1, 2, 3}
+	appLog.VMState = 2
+	appLog.Fault = "fault"
+	appLog.GasConsumed = 100
+	appLog.Stack = [][]byte{{0x00, 0x01, 0x01}}
+	appLog.Notifications = []*NotifyEvent{{CodeHash: common.Uint168{0x1c, 1}, State: []byte{0x00, 0x01, 0x02}}}
+	appLog.Logs = []*LogEvent{{CodeHash: common.Uint168{0x1c, 2}, Message: "log"}}
+
+	b := new(bytes.Buffer)
+	err := appLog.Serialize(b)
+	assert.NoError(t, err)
+
+	appLog2 := ApplicationLog{}
+	err = appLog2.Deserialize(b)
+	assert.NoError(t, err)
+	assert.Equal(t, appLog, appLog2)
+}
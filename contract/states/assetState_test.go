@@ -2,7 +2,6 @@ package states
 
 import (
 	"testing"
-	"math/big"
 	"bytes"
 
 	"github.com/stretchr/testify/assert"
@@ -25,15 +24,16 @@ func TestAssetState(t *testing.T) {
 	assetState.FeeMode = 1
 	assetState.Fee = 234
 	assetState.FeeAddress = common.Uint168{1, 2, 3}
-	pubKey := crypto.PublicKey{X: big.NewInt(1), Y: big.NewInt(2)}
-	assetState.Owner = &pubKey
+	_, pubKey, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	assetState.Owner = pubKey
 	assetState.Admin = common.Uint168{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 2}
 	assetState.Issuer = common.Uint168{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 1}
 	assetState.Expiration = 233
 	assetState.IsFrozen = true
 
 	b := new(bytes.Buffer)
-	err := assetState.Serialize(b)
+	err = assetState.Serialize(b)
 	assert.True(t, err == nil)
 
 	asssetState2 := AssetState{}
@@ -0,0 +1,154 @@
+package states
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+// ContractEvent is a notification raised by a persisted transaction. Index is
+// the position of the notification within the transaction.
+type ContractEvent struct {
+	StateBase
+	CodeHash  common.Uint168
+	Height    uint32
+	TxID      common.Uint256
+	Index     uint32
+	EventName string
+	State     []byte
+}
+
+func (event *ContractEvent) Serialize(w io.Writer) error {
+	event.StateBase.Serialize(w)
+	if err := event.CodeHash.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteUint32(w, event.Height); err != nil {
+		return err
+	}
+	if err := event.TxID.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteUint32(w, event.Index); err != nil {
+		return err
+	}
+	if err := common.WriteVarString(w, event.EventName); err != nil {
+		return err
+	}
+	return common.WriteVarBytes(w, event.State)
+}
+
+func (event *ContractEvent) Deserialize(r io.Reader) error {
+	stateBase := new(StateBase)
+	if err := stateBase.Deserialize(r); err != nil {
+		return err
+	}
+	event.StateBase = *stateBase
+	if err := event.CodeHash.Deserialize(r); err != nil {
+		return errors.New("ContractEvent CodeHash Deserialize fail.")
+	}
+	height, err := common.ReadUint32(r)
+	if err != nil {
+		return errors.New("ContractEvent Height Deserialize fail.")
+	}
+	event.Height = height
+	if err := event.TxID.Deserialize(r); err != nil {
+		return errors.New("ContractEvent TxID Deserialize fail.")
+	}
+	index, err := common.ReadUint32(r)
+	if err != nil {
+		return errors.New("ContractEvent Index Deserialize fail.")
+	}
+	event.Index = index
+	name, err := common.ReadVarString(r)
+	if err != nil {
+		return errors.New("ContractEvent EventName Deserialize fail.")
+	}
+	event.EventName = name
+	state, err := common.ReadVarBytes(r, common.MaxVarStringLength, "ContractEvent State")
+	if err != nil {
+		return errors.New("ContractEvent State Deserialize fail.")
+	}
+	event.State = state
+	return nil
+}
+
+func (event *ContractEvent) Bytes() []byte {
+	b := new(bytes.Buffer)
+	event.Serialize(b)
+	return b.Bytes()
+}
+
+// ContractEventPrefix is the prefix of the events a contract raised in a
+// block. The events are keyed by height first, so the events of a block range
+// are read in order and the event bloom of a block tells whether its events
+// need to be read at all.
+func ContractEventPrefix(height uint32, codeHash *common.Uint168) []byte {
+	key := []byte{byte(IX_ContractEvent), byte(height >> 24), byte(height >> 16), byte(height >> 8), byte(height)}
+	return append(key, codeHash.Bytes()...)
+}
+
+// ContractEventKey is the key of an event, seq is the position of the event
+// among the events of its block.
+func ContractEventKey(height uint32, codeHash *common.Uint168, seq uint32) []byte {
+	return append(ContractEventPrefix(height, codeHash), byte(seq>>24), byte(seq>>16), byte(seq>>8), byte(seq))
+}
+
+func EventBloomKey(height uint32) []byte {
+	return []byte{byte(ST_EventBloom), byte(height >> 24), byte(height >> 16), byte(height >> 8), byte(height)}
+}
+
+// EventBloomSize is the size in bytes of an event bloom.
+const EventBloomSize = 256
+
+// EventBloom is a bloom filter of the contracts and the contract events of a
+// block, it is only stored for blocks with events.
+type EventBloom [EventBloomSize]byte
+
+// AddEvent adds the contract and the contract event name to the bloom.
+func (bloom *EventBloom) AddEvent(codeHash *common.Uint168, eventName string) {
+	bloom.add(codeHash.Bytes())
+	bloom.add(eventBloomName(codeHash, eventName))
+}
+
+// Test returns false if the block has no event of the contract, or no event
+// with the name if the name is not empty. A true result may be wrong.
+func (bloom *EventBloom) Test(codeHash *common.Uint168, eventName string) bool {
+	if eventName == "" {
+		return bloom.test(codeHash.Bytes())
+	}
+	return bloom.test(eventBloomName(codeHash, eventName))
+}
+
+func eventBloomName(codeHash *common.Uint168, eventName string) []byte {
+	return append(codeHash.Bytes(), eventName...)
+}
+
+// bloomBits returns the three bits set for data, each taken from a pair of
+// bytes of the sha256 of data.
+func bloomBits(data []byte) [3]uint {
+	hash := sha256.Sum256(data)
+	var bits [3]uint
+	for i := range bits {
+		bits[i] = (uint(hash[2*i])<<8 | uint(hash[2*i+1])) % (EventBloomSize * 8)
+	}
+	return bits
+}
+
+func (bloom *EventBloom) add(data []byte) {
+	for _, bit := range bloomBits(data) {
+		bloom[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (bloom *EventBloom) test(data []byte) bool {
+	for _, bit := range bloomBits(data) {
+		if bloom[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package states
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+func TestContractEvent(t *testing.T) {
+	event := ContractEvent{}
+	event.CodeHash = common.Uint168{0x1c, 1, 2, 3}
+	event.Height = 100
+	event.TxID = common.Uint256{4, 5, 6}
+	event.Index = 2
+	event.EventName = "transfer"
+	event.State = []byte{0xff, 0x01, 0x00}
+
+	b := new(bytes.Buffer)
+	err := event.Serialize(b)
+	assert.NoError(t, err)
+
+	event2 := ContractEvent{}
+	err = event2.Deserialize(b)
+	assert.NoError(t, err)
+	assert.Equal(t, event, event2)
+}
+
+func TestEventBloom(t *testing.T) {
+	contract := common.Uint168{0x1c, 1}
+	other := common.Uint168{0x1c, 2}
+
+	var bloom EventBloom
+	assert.False(t, bloom.Test(&contract, ""))
+	bloom.AddEvent(&contract, "transfer")
+	assert.True(t, bloom.Test(&contract, ""))
+	assert.True(t, bloom.Test(&contract, "transfer"))
+	assert.False(t, bloom.Test(&contract, "approve"))
+	assert.False(t, bloom.Test(&other, ""))
+}
@@ -0,0 +1,162 @@
+package states
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+type ContractStatus byte
+
+const (
+	ContractDeployed ContractStatus = iota
+	ContractMigrated
+	ContractDestroyed
+)
+
+func (status ContractStatus) String() string {
+	switch status {
+	case ContractDeployed:
+		return "deployed"
+	case ContractMigrated:
+		return "migrated"
+	case ContractDestroyed:
+		return "destroyed"
+	}
+	return "unknown"
+}
+
+// ContractIndexVersion is the state version of the indexes that record the
+// standards of the contract, the indexes of an older version have none.
+const ContractIndexVersion = 1
+
+// Standards a contract can be detected to implement.
+const (
+	StandardNEP5  = "NEP-5"
+	StandardNEP11 = "NEP-11"
+)
+
+// ContractIndex records where a contract comes from and what happened to it,
+// so the deployed contracts can be enumerated.
+type ContractIndex struct {
+	StateBase
+	CodeHash     common.Uint168
+	DeployTx     common.Uint256
+	Height       uint32
+	Author       string
+	Name         string
+	Status       ContractStatus
+	UpdateTx     common.Uint256
+	UpdateHeight uint32
+	MigratedTo   common.Uint168
+	Standards    []string
+}
+
+func (index *ContractIndex) Serialize(w io.Writer) error {
+	index.StateBase.Serialize(w)
+	if err := index.CodeHash.Serialize(w); err != nil {
+		return err
+	}
+	if err := index.DeployTx.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteUint32(w, index.Height); err != nil {
+		return err
+	}
+	if err := common.WriteVarString(w, index.Author); err != nil {
+		return err
+	}
+	if err := common.WriteVarString(w, index.Name); err != nil {
+		return err
+	}
+	if err := common.WriteUint8(w, uint8(index.Status)); err != nil {
+		return err
+	}
+	if err := index.UpdateTx.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteUint32(w, index.UpdateHeight); err != nil {
+		return err
+	}
+	if err := index.MigratedTo.Serialize(w); err != nil {
+		return err
+	}
+	if index.StateVersion < ContractIndexVersion {
+		return nil
+	}
+	if err := common.WriteVarUint(w, uint64(len(index.Standards))); err != nil {
+		return err
+	}
+	for _, standard := range index.Standards {
+		if err := common.WriteVarString(w, standard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (index *ContractIndex) Deserialize(r io.Reader) error {
+	stateBase := new(StateBase)
+	if err := stateBase.Deserialize(r); err != nil {
+		return err
+	}
+	index.StateBase = *stateBase
+	if err := index.CodeHash.Deserialize(r); err != nil {
+		return errors.New("ContractIndex CodeHash Deserialize fail.")
+	}
+	if err := index.DeployTx.Deserialize(r); err != nil {
+		return errors.New("ContractIndex DeployTx Deserialize fail.")
+	}
+	height, err := common.ReadUint32(r)
+	if err != nil {
+		return errors.New("ContractIndex Height Deserialize fail.")
+	}
+	index.Height = height
+	index.Author, err = common.ReadVarString(r)
+	if err != nil {
+		return errors.New("ContractIndex Author Deserialize fail.")
+	}
+	index.Name, err = common.ReadVarString(r)
+	if err != nil {
+		return errors.New("ContractIndex Name Deserialize fail.")
+	}
+	status, err := common.ReadUint8(r)
+	if err != nil {
+		return errors.New("ContractIndex Status Deserialize fail.")
+	}
+	index.Status = ContractStatus(status)
+	if err := index.UpdateTx.Deserialize(r); err != nil {
+		return errors.New("ContractIndex UpdateTx Deserialize fail.")
+	}
+	index.UpdateHeight, err = common.ReadUint32(r)
+	if err != nil {
+		return errors.New("ContractIndex UpdateHeight Deserialize fail.")
+	}
+	if err := index.MigratedTo.Deserialize(r); err != nil {
+		return errors.New("ContractIndex MigratedTo Deserialize fail.")
+	}
+	if index.StateVersion < ContractIndexVersion {
+		return nil
+	}
+	count, err := common.ReadVarUint(r, 0)
+	if err != nil {
+		return errors.New("ContractIndex Standards Deserialize fail.")
+	}
+	index.Standards = nil
+	for i := uint64(0); i < count; i++ {
+		standard, err := common.ReadVarString(r)
+		if err != nil {
+			return errors.New("ContractIndex Standards Deserialize fail.")
+		}
+		index.Standards = append(index.Standards, standard)
+	}
+	return nil
+}
+
+func (index *ContractIndex) Bytes() []byte {
+	b := new(bytes.Buffer)
+	index.Serialize(b)
+	return b.Bytes()
+}
@@ -0,0 +1,59 @@
+package states
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+func TestContractIndex(t *testing.T) {
+	index := ContractIndex{}
+	index.CodeHash = common.Uint168{0x1c, 1, 2, 3}
+	index.DeployTx = common.Uint256{4, 5, 6}
+	index.Height = 100
+	index.Author = "author"
+	index.Name = "name"
+	index.Status = ContractMigrated
+	index.UpdateTx = common.Uint256{7, 8, 9}
+	index.UpdateHeight = 200
+	index.MigratedTo = common.Uint168{0x1c, 3, 2, 1}
+
+	b := new(bytes.Buffer)
+	err := index.Serialize(b)
+	assert.NoError(t, err)
+
+	index2 := ContractIndex{}
+	err = index2.Deserialize(b)
+	assert.NoError(t, err)
+	assert.Equal(t, index, index2)
+	assert.Equal(t, "migrated", index2.Status.String())
+}
+
+func TestContractIndexStandards(t *testing.T) {
+	index := ContractIndex{}
+	index.StateVersion = ContractIndexVersion
+	index.CodeHash = common.Uint168{0x1c, 1, 2, 3}
+	index.Height = 100
+	index.Standards = []string{StandardNEP5}
+
+	b := new(bytes.Buffer)
+	err := index.Serialize(b)
+	assert.NoError(t, err)
+
+	index2 := ContractIndex{}
+	err = index2.Deserialize(b)
+	assert.NoError(t, err)
+	assert.Equal(t, index, index2)
+
+	// the indexes of the previous version have no standards.
+	index.StateVersion = 0
+	b.Reset()
+	assert.NoError(t, index.Serialize(b))
+	index2 = ContractIndex{}
+	assert.NoError(t, index2.Deserialize(b))
+	assert.Equal(t, 0, len(index2.Standards))
+	assert.Equal(t, 0, b.Len())
+}
@@ -140,3 +140,8 @@ func (contractState *ContractState) IsMultiSigContract() bool {
 func (contractState *ContractState) IsSignatureCotract() bool {
 	return contract.IsSignatureCotract(contractState.Code.Code)
 }
+
+// ContractManifestKey is the key of the manifest a contract was deployed with.
+func ContractManifestKey(codeHash *common.Uint168) []byte {
+	return append([]byte{byte(ST_ContractManifest)}, codeHash.Bytes()...)
+}
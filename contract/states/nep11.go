@@ -0,0 +1,79 @@
+package states
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+// MaxNEP11TokenIDSize is the max size of the id of a NEP-11 token.
+const MaxNEP11TokenIDSize = 64
+
+// NEP11Transfer is a transfer notification of a NEP-11 token, the transfer of
+// an amount of the token with TokenID.
+type NEP11Transfer struct {
+	NEP5Transfer
+	TokenID []byte
+}
+
+func (transfer *NEP11Transfer) Serialize(w io.Writer) error {
+	if err := transfer.NEP5Transfer.Serialize(w); err != nil {
+		return err
+	}
+	return common.WriteVarBytes(w, transfer.TokenID)
+}
+
+func (transfer *NEP11Transfer) Deserialize(r io.Reader) error {
+	if err := transfer.NEP5Transfer.Deserialize(r); err != nil {
+		return err
+	}
+	tokenID, err := common.ReadVarBytes(r, MaxNEP11TokenIDSize, "NEP11Transfer TokenID")
+	if err != nil {
+		return errors.New("NEP11Transfer TokenID Deserialize fail.")
+	}
+	transfer.TokenID = tokenID
+	return nil
+}
+
+func (transfer *NEP11Transfer) Bytes() []byte {
+	b := new(bytes.Buffer)
+	transfer.Serialize(b)
+	return b.Bytes()
+}
+
+// NEP11BalancePrefix is the prefix of the NEP-11 tokens an account owns, the
+// balance of a token is stored as a NEP5Balance.
+func NEP11BalancePrefix(address []byte) []byte {
+	return append([]byte{byte(ST_NEP11Balance)}, address...)
+}
+
+func NEP11BalanceKey(address []byte, assetHash *common.Uint168, tokenID []byte) []byte {
+	key := append(NEP11BalancePrefix(address), assetHash.Bytes()...)
+	return append(key, tokenID...)
+}
+
+// NEP11OwnerPrefix is the prefix of the accounts that own a NEP-11 token.
+// The token id is length prefixed so the owners of a token do not share the
+// prefix of a token whose id starts with the same bytes.
+func NEP11OwnerPrefix(assetHash *common.Uint168, tokenID []byte) []byte {
+	key := append([]byte{byte(IX_NEP11Owner)}, assetHash.Bytes()...)
+	key = append(key, byte(len(tokenID)))
+	return append(key, tokenID...)
+}
+
+func NEP11OwnerKey(assetHash *common.Uint168, tokenID []byte, address []byte) []byte {
+	return append(NEP11OwnerPrefix(assetHash, tokenID), address...)
+}
+
+func NEP11TransferPrefix(address []byte) []byte {
+	return append([]byte{byte(IX_NEP11Transfer)}, address...)
+}
+
+// NEP11TransferKey is the key of a NEP-11 transfer of an account, seq is the
+// position of the transfer among the NEP-11 transfers of its block.
+func NEP11TransferKey(address []byte, height uint32, seq uint32) []byte {
+	return append(NEP11TransferPrefix(address), byte(height>>24), byte(height>>16), byte(height>>8), byte(height),
+		byte(seq>>24), byte(seq>>16), byte(seq>>8), byte(seq))
+}
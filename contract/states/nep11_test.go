@@ -0,0 +1,36 @@
+package states
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+func TestNEP11Transfer(t *testing.T) {
+	transfer := NEP11Transfer{}
+	transfer.AssetHash = common.Uint168{0x1c, 1, 2, 3}
+	transfer.From = bytes.Repeat([]byte{4}, NEP5AddressSize)
+	transfer.To = bytes.Repeat([]byte{5}, NEP5AddressSize)
+	transfer.Amount = big.NewInt(1)
+	transfer.TxID = common.Uint256{6, 7, 8}
+	transfer.Height = 100
+	transfer.TokenID = []byte("token")
+
+	transfer2 := NEP11Transfer{}
+	err := transfer2.Deserialize(bytes.NewReader(transfer.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, transfer, transfer2)
+}
+
+func TestNEP11OwnerKey(t *testing.T) {
+	assetHash := common.Uint168{0x1c, 1}
+	address := bytes.Repeat([]byte{0x74}, NEP5AddressSize)
+	// the owners of a token are not listed with a token whose id it prefixes.
+	key := NEP11OwnerKey(&assetHash, []byte("tokent"), address)
+	assert.False(t, bytes.HasPrefix(key, NEP11OwnerPrefix(&assetHash, []byte("token"))))
+	assert.True(t, bytes.HasPrefix(key, NEP11OwnerPrefix(&assetHash, []byte("tokent"))))
+}
@@ -0,0 +1,204 @@
+package states
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+// NEP5AddressSize is the size of the addresses the NEP-5 index is keyed by,
+// the 21 bytes program hashes are indexed without their prefix so both forms
+// a contract may use for an account share the same entries.
+const NEP5AddressSize = 20
+
+// NEP5Balance is the balance of an account in a NEP-5 token, the sum of the
+// transfers of the token to and from the account.
+type NEP5Balance struct {
+	StateBase
+	Balance           *big.Int
+	LastUpdatedHeight uint32
+}
+
+func (balance *NEP5Balance) Serialize(w io.Writer) error {
+	balance.StateBase.Serialize(w)
+	if err := writeBigInt(w, balance.Balance); err != nil {
+		return err
+	}
+	return common.WriteUint32(w, balance.LastUpdatedHeight)
+}
+
+func (balance *NEP5Balance) Deserialize(r io.Reader) error {
+	stateBase := new(StateBase)
+	if err := stateBase.Deserialize(r); err != nil {
+		return err
+	}
+	balance.StateBase = *stateBase
+	value, err := readBigInt(r)
+	if err != nil {
+		return errors.New("NEP5Balance Balance Deserialize fail.")
+	}
+	balance.Balance = value
+	balance.LastUpdatedHeight, err = common.ReadUint32(r)
+	if err != nil {
+		return errors.New("NEP5Balance LastUpdatedHeight Deserialize fail.")
+	}
+	return nil
+}
+
+func (balance *NEP5Balance) Bytes() []byte {
+	b := new(bytes.Buffer)
+	balance.Serialize(b)
+	return b.Bytes()
+}
+
+// NEP5Transfer is a transfer notification of a NEP-5 token. From and To are
+// the accounts as the contract raised them, empty for a mint or a burn.
+type NEP5Transfer struct {
+	StateBase
+	AssetHash common.Uint168
+	From      []byte
+	To        []byte
+	Amount    *big.Int
+	TxID      common.Uint256
+	Height    uint32
+	Timestamp uint32
+	Index     uint32
+}
+
+func (transfer *NEP5Transfer) Serialize(w io.Writer) error {
+	transfer.StateBase.Serialize(w)
+	if err := transfer.AssetHash.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteVarBytes(w, transfer.From); err != nil {
+		return err
+	}
+	if err := common.WriteVarBytes(w, transfer.To); err != nil {
+		return err
+	}
+	if err := writeBigInt(w, transfer.Amount); err != nil {
+		return err
+	}
+	if err := transfer.TxID.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteUint32(w, transfer.Height); err != nil {
+		return err
+	}
+	if err := common.WriteUint32(w, transfer.Timestamp); err != nil {
+		return err
+	}
+	return common.WriteUint32(w, transfer.Index)
+}
+
+func (transfer *NEP5Transfer) Deserialize(r io.Reader) error {
+	stateBase := new(StateBase)
+	if err := stateBase.Deserialize(r); err != nil {
+		return err
+	}
+	transfer.StateBase = *stateBase
+	if err := transfer.AssetHash.Deserialize(r); err != nil {
+		return errors.New("NEP5Transfer AssetHash Deserialize fail.")
+	}
+	var err error
+	transfer.From, err = common.ReadVarBytes(r, common.MaxVarStringLength, "NEP5Transfer From")
+	if err != nil {
+		return errors.New("NEP5Transfer From Deserialize fail.")
+	}
+	transfer.To, err = common.ReadVarBytes(r, common.MaxVarStringLength, "NEP5Transfer To")
+	if err != nil {
+		return errors.New("NEP5Transfer To Deserialize fail.")
+	}
+	transfer.Amount, err = readBigInt(r)
+	if err != nil {
+		return errors.New("NEP5Transfer Amount Deserialize fail.")
+	}
+	if err := transfer.TxID.Deserialize(r); err != nil {
+		return errors.New("NEP5Transfer TxID Deserialize fail.")
+	}
+	transfer.Height, err = common.ReadUint32(r)
+	if err != nil {
+		return errors.New("NEP5Transfer Height Deserialize fail.")
+	}
+	transfer.Timestamp, err = common.ReadUint32(r)
+	if err != nil {
+		return errors.New("NEP5Transfer Timestamp Deserialize fail.")
+	}
+	transfer.Index, err = common.ReadUint32(r)
+	if err != nil {
+		return errors.New("NEP5Transfer Index Deserialize fail.")
+	}
+	return nil
+}
+
+func (transfer *NEP5Transfer) Bytes() []byte {
+	b := new(bytes.Buffer)
+	transfer.Serialize(b)
+	return b.Bytes()
+}
+
+// NEP5Address returns the address the NEP-5 index uses for an account raised
+// by a contract, it returns nil if data is not an account.
+func NEP5Address(data []byte) []byte {
+	switch len(data) {
+	case NEP5AddressSize:
+		return data
+	case NEP5AddressSize + 1:
+		return data[1:]
+	}
+	return nil
+}
+
+func NEP5BalancePrefix(address []byte) []byte {
+	return append([]byte{byte(ST_NEP5Balance)}, address...)
+}
+
+func NEP5BalanceKey(address []byte, assetHash *common.Uint168) []byte {
+	return append(NEP5BalancePrefix(address), assetHash.Bytes()...)
+}
+
+// NEP5TransferPrefix is the prefix of the transfers of an account, they are
+// keyed by height so a height range is read in order.
+func NEP5TransferPrefix(address []byte) []byte {
+	return append([]byte{byte(IX_NEP5Transfer)}, address...)
+}
+
+// NEP5TransferKey is the key of a transfer of an account, seq is the position
+// of the transfer among the transfers of its block.
+func NEP5TransferKey(address []byte, height uint32, seq uint32) []byte {
+	return append(NEP5TransferPrefix(address), byte(height>>24), byte(height>>16), byte(height>>8), byte(height),
+		byte(seq>>24), byte(seq>>16), byte(seq>>8), byte(seq))
+}
+
+// writeBigInt writes the sign and the absolute value of an integer.
+func writeBigInt(w io.Writer, value *big.Int) error {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if err := common.WriteUint8(w, uint8(value.Sign()+1)); err != nil {
+		return err
+	}
+	return common.WriteVarBytes(w, value.Bytes())
+}
+
+func readBigInt(r io.Reader) (*big.Int, error) {
+	sign, err := common.ReadUint8(r)
+	if err != nil {
+		return nil, err
+	}
+	if sign > 2 {
+		return nil, errors.New("invalid integer sign")
+	}
+	data, err := common.ReadVarBytes(r, common.MaxVarStringLength, "integer")
+	if err != nil {
+		return nil, err
+	}
+	value := new(big.Int).SetBytes(data)
+	if sign == 0 {
+		value.Neg(value)
+	}
+	return value, nil
+}
@@ -0,0 +1,44 @@
+package states
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+func TestNEP5Balance(t *testing.T) {
+	for _, value := range []int64{0, 1, -1, 1000000000000} {
+		balance := NEP5Balance{Balance: big.NewInt(value), LastUpdatedHeight: 100}
+
+		balance2 := NEP5Balance{}
+		err := balance2.Deserialize(bytes.NewReader(balance.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, balance.Balance.Cmp(balance2.Balance))
+		assert.Equal(t, balance.LastUpdatedHeight, balance2.LastUpdatedHeight)
+	}
+}
+
+func TestNEP5Transfer(t *testing.T) {
+	transfer := NEP5Transfer{}
+	transfer.AssetHash = common.Uint168{0x1c, 1, 2, 3}
+	transfer.From = []byte{}
+	transfer.To = bytes.Repeat([]byte{4}, NEP5AddressSize)
+	transfer.Amount = big.NewInt(500)
+	transfer.TxID = common.Uint256{5, 6, 7}
+	transfer.Height = 100
+	transfer.Timestamp = 1500000000
+	transfer.Index = 1
+
+	transfer2 := NEP5Transfer{}
+	err := transfer2.Deserialize(bytes.NewReader(transfer.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, transfer, transfer2)
+
+	assert.Nil(t, NEP5Address(transfer.From))
+	assert.Equal(t, transfer.To, NEP5Address(transfer.To))
+	assert.Equal(t, transfer.To, NEP5Address(append([]byte{0x21}, transfer.To...)))
+}
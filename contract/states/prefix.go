@@ -0,0 +1,24 @@
+package states
+
+import (
+	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
+)
+
+// Entry prefixes of the data persisted by the NeoVM side chain in addition
+// to the ones defined by the side chain store.
+const (
+	IX_Contract         blockchain.EntryPrefix = 0xb0
+	ST_ApplicationLog   blockchain.EntryPrefix = 0xb1
+	SYS_ContractState   blockchain.EntryPrefix = 0xb2
+	ST_StateRoot        blockchain.EntryPrefix = 0xb3
+	ST_TrieNode         blockchain.EntryPrefix = 0xb4
+	ST_UndoJournal      blockchain.EntryPrefix = 0xb5
+	IX_ContractEvent    blockchain.EntryPrefix = 0xb6
+	ST_EventBloom       blockchain.EntryPrefix = 0xb7
+	ST_NEP5Balance      blockchain.EntryPrefix = 0xb8
+	IX_NEP5Transfer     blockchain.EntryPrefix = 0xb9
+	ST_NEP11Balance     blockchain.EntryPrefix = 0xba
+	IX_NEP11Owner       blockchain.EntryPrefix = 0xbb
+	IX_NEP11Transfer    blockchain.EntryPrefix = 0xbc
+	ST_ContractManifest blockchain.EntryPrefix = 0xbd
+)
@@ -0,0 +1,75 @@
+package states
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+// StateRoot summarizes the contract state after a block. ChangesRoot is the
+// merkle root of the entries written by the block, and Root chains it to the
+// root of the previous block. StorageRoot is the root of the trie of the
+// contract storage, which storage proofs are verified against.
+type StateRoot struct {
+	StateBase
+	Height      uint32
+	BlockHash   common.Uint256
+	ChangesRoot common.Uint256
+	Root        common.Uint256
+	StorageRoot common.Uint256
+}
+
+func (root *StateRoot) Serialize(w io.Writer) error {
+	root.StateBase.Serialize(w)
+	if err := common.WriteUint32(w, root.Height); err != nil {
+		return err
+	}
+	if err := root.BlockHash.Serialize(w); err != nil {
+		return err
+	}
+	if err := root.ChangesRoot.Serialize(w); err != nil {
+		return err
+	}
+	if err := root.Root.Serialize(w); err != nil {
+		return err
+	}
+	return root.StorageRoot.Serialize(w)
+}
+
+func (root *StateRoot) Deserialize(r io.Reader) error {
+	stateBase := new(StateBase)
+	if err := stateBase.Deserialize(r); err != nil {
+		return err
+	}
+	root.StateBase = *stateBase
+	height, err := common.ReadUint32(r)
+	if err != nil {
+		return errors.New("StateRoot Height Deserialize fail.")
+	}
+	root.Height = height
+	if err := root.BlockHash.Deserialize(r); err != nil {
+		return errors.New("StateRoot BlockHash Deserialize fail.")
+	}
+	if err := root.ChangesRoot.Deserialize(r); err != nil {
+		return errors.New("StateRoot ChangesRoot Deserialize fail.")
+	}
+	if err := root.Root.Deserialize(r); err != nil {
+		return errors.New("StateRoot Root Deserialize fail.")
+	}
+	if err := root.StorageRoot.Deserialize(r); err != nil {
+		return errors.New("StateRoot StorageRoot Deserialize fail.")
+	}
+	return nil
+}
+
+func (root *StateRoot) Bytes() []byte {
+	b := new(bytes.Buffer)
+	root.Serialize(b)
+	return b.Bytes()
+}
+
+func StateRootKey(height uint32) []byte {
+	return []byte{byte(ST_StateRoot), byte(height >> 24), byte(height >> 16), byte(height >> 8), byte(height)}
+}
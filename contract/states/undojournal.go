@@ -0,0 +1,104 @@
+package states
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+// UndoEntry is the value a key had before a block was persisted, Exists is
+// false if the key was absent.
+type UndoEntry struct {
+	Key    []byte
+	Exists bool
+	Value  []byte
+}
+
+// UndoJournal records the contract state overwritten by a block, so the block
+// can be rolled back when it is disconnected from the chain.
+type UndoJournal struct {
+	StateBase
+	Height    uint32
+	BlockHash common.Uint256
+	Entries   []UndoEntry
+}
+
+func (journal *UndoJournal) Serialize(w io.Writer) error {
+	journal.StateBase.Serialize(w)
+	if err := common.WriteUint32(w, journal.Height); err != nil {
+		return err
+	}
+	if err := journal.BlockHash.Serialize(w); err != nil {
+		return err
+	}
+	if err := common.WriteVarUint(w, uint64(len(journal.Entries))); err != nil {
+		return err
+	}
+	for _, entry := range journal.Entries {
+		if err := common.WriteVarBytes(w, entry.Key); err != nil {
+			return err
+		}
+		var exists uint8
+		if entry.Exists {
+			exists = 1
+		}
+		if err := common.WriteUint8(w, exists); err != nil {
+			return err
+		}
+		if err := common.WriteVarBytes(w, entry.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (journal *UndoJournal) Deserialize(r io.Reader) error {
+	stateBase := new(StateBase)
+	if err := stateBase.Deserialize(r); err != nil {
+		return err
+	}
+	journal.StateBase = *stateBase
+	height, err := common.ReadUint32(r)
+	if err != nil {
+		return errors.New("UndoJournal Height Deserialize fail.")
+	}
+	journal.Height = height
+	if err := journal.BlockHash.Deserialize(r); err != nil {
+		return errors.New("UndoJournal BlockHash Deserialize fail.")
+	}
+	count, err := common.ReadVarUint(r, 0)
+	if err != nil {
+		return errors.New("UndoJournal Entries Deserialize fail.")
+	}
+	journal.Entries = make([]UndoEntry, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var entry UndoEntry
+		entry.Key, err = common.ReadVarBytes(r, common.MaxVarStringLength, "UndoJournal Deserialize Key")
+		if err != nil {
+			return err
+		}
+		exists, err := common.ReadUint8(r)
+		if err != nil {
+			return errors.New("UndoJournal Exists Deserialize fail.")
+		}
+		entry.Exists = exists == 1
+		entry.Value, err = common.ReadVarBytes(r, common.MaxVarStringLength, "UndoJournal Deserialize Value")
+		if err != nil {
+			return err
+		}
+		journal.Entries = append(journal.Entries, entry)
+	}
+	return nil
+}
+
+func (journal *UndoJournal) Bytes() []byte {
+	b := new(bytes.Buffer)
+	journal.Serialize(b)
+	return b.Bytes()
+}
+
+func UndoJournalKey(height uint32) []byte {
+	return []byte{byte(ST_UndoJournal), byte(height >> 24), byte(height >> 16), byte(height >> 8), byte(height)}
+}
@@ -10,6 +10,8 @@ const (
 	ETRunTimeLog        events.EventType = 0x51
 	ETDeployTransaction events.EventType = 0x52
 	ETInvokeTransaction events.EventType = 0x53
+	ETBlockPersisted    events.EventType = 0x54
+	ETContractChanged   events.EventType = 0x55
 )
 
 var notificationStrings = map[events.EventType]string{
@@ -17,4 +19,6 @@ var notificationStrings = map[events.EventType]string{
 	ETRunTimeLog:        "ETRunTimeLog",
 	ETDeployTransaction: "ETDeployTransaction",
 	ETInvokeTransaction: "ETInvokeTransaction",
+	ETBlockPersisted:    "ETBlockPersisted",
+	ETContractChanged:   "ETContractChanged",
 }
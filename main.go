@@ -33,8 +33,10 @@ import (
 	nc "github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/store"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/service/websocket"
+	ns "github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
 )
 
 const (
@@ -66,6 +68,12 @@ func main() {
 		os.Exit(-1)
 	}
 
+	reindexCfg, err := parseReindexArgs(os.Args[1:])
+	if err != nil {
+		eladlog.Fatalf("parse arguments failed %s", err)
+		os.Exit(-1)
+	}
+
 	// listen interrupt signals.
 	interrupt := signal.NewInterrupt()
 
@@ -119,10 +127,14 @@ func main() {
 	}
 	mempoolCfg.SpvService = spvService
 
-	defer spvService.Stop()
-	spvService.Start()
+	if !reindexCfg.Offline {
+		defer spvService.Stop()
+		spvService.Start()
+	}
 
-	txValidator := mp.NewValidator(&mempoolCfg)
+	avm.VMForkHeight = activeForkConfig.VMHeight
+	ns.RuntimeForkHeight = activeForkConfig.RuntimeHeight
+	txValidator := mp.NewValidator(&mempoolCfg, activeGasConfig, activeForkConfig)
 	mempoolCfg.Validator = txValidator
 	chainCfg.CheckTxSanity = txValidator.CheckTransactionSanity
 	chainCfg.CheckTxContext = txValidator.CheckTransactionContext
@@ -150,7 +162,38 @@ func main() {
 		ledgerStore.Put([]byte(store.AccountPersisFlag), flag)
 	}
 
+	trieComplete, err := ledgerStore.CheckStorageTrie()
+	if err != nil {
+		eladlog.Fatalf("check storage trie failed, %s", err)
+		os.Exit(1)
+	}
+	if !trieComplete {
+		if !reindexCfg.Enabled {
+			eladlog.Fatalf("The storage trie is not built yet, the contract state has to be "+
+				"reindexed once: start with --reindex-contracts, or run %s %s to reindex "+
+				"without starting the node", os.Args[0], reindexCommand)
+			os.Exit(1)
+		}
+		eladlog.Info("The storage trie is not built yet, the reindex builds it")
+	}
+	if reindexCfg.Enabled {
+		if err := reindexContracts(ledgerStore, reindexCfg); err != nil {
+			eladlog.Fatalf("reindex contract state failed, %s", err)
+			os.Exit(1)
+		}
+		if reindexCfg.Offline {
+			return
+		}
+	}
+
+	if err := ledgerStore.CheckContractState(); err != nil {
+		eladlog.Fatalf("contract state is inconsistent with the chain, %s", err)
+		os.Exit(1)
+	}
+
 	sv.Store = ledgerStore
+	sv.GasConfig = activeGasConfig
+	sv.ForkConfig = activeForkConfig
 	sv.Table = store.NewCacheCodeTable(nc.NewDBCache(ledgerStore))
 
 	txPool := mempool.New(&mempoolCfg)
@@ -209,7 +252,7 @@ func main() {
 		}
 	}()
 
-	restServer := newRESTfulServer(cfg.HttpRestPort, service.HttpService)
+	restServer := newRESTfulServer(cfg.HttpRestPort, service)
 	defer restServer.Stop()
 	go func() {
 		if err := restServer.Start(); err != nil {
@@ -217,13 +260,21 @@ func main() {
 		}
 	}()
 
-	socketServer := newWebSocketServer(cfg.HttpWsPort, service.HttpService)
+	socketServer := newWebSocketServer(cfg.HttpWsPort, cfg.HttpWsSubscribePort, service.HttpService)
 	defer socketServer.Server.Stop()
 	go func() {
 		if err := socketServer.Server.Start(); err != nil {
 			sockLog.Errorf("Start HttpSocket server failed, %s", err.Error())
 		}
 	}()
+	if socketServer.Subscriptions != nil {
+		defer socketServer.Subscriptions.Stop()
+		go func() {
+			if err := socketServer.Subscriptions.Start(); err != nil {
+				sockLog.Errorf("Start HttpSocket subscription server failed, %s", err.Error())
+			}
+		}()
+	}
 
 	if cfg.PrintSyncState {
 		go printSyncState(ledgerStore.ChainStore, server)
@@ -231,6 +282,7 @@ func main() {
 
 	events.Subscribe(handleRunTimeEvents)
 	events.Subscribe(socketServer.OnEvent)
+	events.Subscribe(sv.OnContractChanged)
 
 	<-interrupt.C
 }
@@ -262,13 +314,31 @@ func newJsonRpcServer(port uint16, service *sv.HttpServiceExtend) *jsonrpc.Serve
 	s.RegisterAction("listunspent", service.ListUnspent, "addresses")
 	s.RegisterAction("getreceivedbyaddress", service.GetReceivedByAddress, "address", "assetid")
 
-	s.RegisterAction("invokescript", service.InvokeScript, "script", "returntype")
-	s.RegisterAction("invokefunction", service.InvokeFunction, "scripthash", "operation", "params", "returntype")
+	s.RegisterAction("invokescript", service.InvokeScript, "script", "returntype", "overrides")
+	s.RegisterAction("invokefunction", service.InvokeFunction, "scripthash", "operation", "params", "returntype", "overrides")
+	s.RegisterAction("invokemulti", service.InvokeMulti, "calls", "failfast")
 	s.RegisterAction("getOpPrice", service.GetOpPrice, "op", "args")
+	s.RegisterAction("getcontractstate", service.GetContractState, "codehash")
+	s.RegisterAction("getcontractmanifest", service.GetContractManifest, "codehash")
+	s.RegisterAction("getstorage", service.GetStorage, "codehash", "key")
+	s.RegisterAction("findstorage", service.FindStorage, "codehash", "prefix", "cursor", "limit")
+	s.RegisterAction("listcontracts", service.ListContracts, "author", "name", "fromheight", "status", "standard", "cursor", "limit")
+	s.RegisterAction("getapplicationlog", service.GetApplicationLog, "txid")
+	s.RegisterAction("getcontractevents", service.GetContractEvents, "codehash", "eventname", "fromheight", "toheight", "cursor", "limit")
+	s.RegisterAction("getnep5balances", service.GetNEP5Balances, "address")
+	s.RegisterAction("getnep5transfers", service.GetNEP5Transfers, "address", "from", "to")
+	s.RegisterAction("getnep11balances", service.GetNEP11Balances, "address")
+	s.RegisterAction("getnep11transfers", service.GetNEP11Transfers, "address", "from", "to")
+	s.RegisterAction("getnep11properties", service.GetNEP11Properties, "contract", "tokenid")
+	s.RegisterAction("getstateroot", service.GetStateRoot, "height")
+	s.RegisterAction("estimategas", service.EstimateGas, "tx", "scripthash", "operation", "params", "signers")
+	s.RegisterAction("builddeploytransaction", service.BuildDeployTransaction, "code", "abi", "name", "version", "author", "email", "description", "programhash", "gas")
+	s.RegisterAction("getproof", service.GetProof, "codehash", "key", "height")
+	s.RegisterAction("verifyproof", service.VerifyProof, "root", "codehash", "key", "proof")
 	return s
 }
 
-func newRESTfulServer(port uint16, service *service.HttpService) *restful.Server {
+func newRESTfulServer(port uint16, service *sv.HttpServiceExtend) *restful.Server {
 	var (
 		s = restful.NewServer(&restful.Config{ServePort: port})
 
@@ -299,6 +369,46 @@ func newRESTfulServer(port uint16, service *service.HttpService) *restful.Server
 			}
 			return service.SendRawTransaction(params)
 		}
+
+		findStorage = func(data []byte) (interface{}, error) {
+			var params = util.Params{}
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, err
+			}
+			return service.FindStorage(params)
+		}
+
+		listContracts = func(data []byte) (interface{}, error) {
+			var params = util.Params{}
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, err
+			}
+			return service.ListContracts(params)
+		}
+
+		getContractEvents = func(data []byte) (interface{}, error) {
+			var params = util.Params{}
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, err
+			}
+			return service.GetContractEvents(params)
+		}
+
+		getNEP5Transfers = func(data []byte) (interface{}, error) {
+			var params = util.Params{}
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, err
+			}
+			return service.GetNEP5Transfers(params)
+		}
+
+		getNEP11Transfers = func(data []byte) (interface{}, error) {
+			var params = util.Params{}
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, err
+			}
+			return service.GetNEP11Transfers(params)
+		}
 	)
 
 	const (
@@ -318,6 +428,19 @@ func newRESTfulServer(port uint16, service *service.HttpService) *restful.Server
 		ApiSendRawTransaction  = "/api/v1/transaction"
 		ApiGetTransactionPool  = "/api/v1/transactionpool"
 		ApiRestart             = "/api/v1/restart"
+		ApiGetContractState    = "/api/v1/contract/:codehash"
+		ApiGetManifest         = "/api/v1/contract/manifest/:codehash"
+		ApiGetStorage          = "/api/v1/contract/storage/:codehash/:key"
+		ApiFindStorage         = "/api/v1/contract/storage"
+		ApiListContracts       = "/api/v1/contracts"
+		ApiGetContractEvents   = "/api/v1/contract/events"
+		ApiGetNEP5Balances     = "/api/v1/nep5/balances/:address"
+		ApiGetNEP5Transfers    = "/api/v1/nep5/transfers"
+		ApiGetNEP11Balances    = "/api/v1/nep11/balances/:address"
+		ApiGetNEP11Transfers   = "/api/v1/nep11/transfers"
+		ApiGetNEP11Properties  = "/api/v1/nep11/properties/:contract/:tokenid"
+		ApiGetApplicationLog   = "/api/v1/applicationlog/:txid"
+		ApiGetStateRoot        = "/api/v1/stateroot/:height"
 	)
 
 	s.RegisterGetAction(ApiGetConnectionCount, service.GetConnectionCount)
@@ -334,18 +457,31 @@ func newRESTfulServer(port uint16, service *service.HttpService) *restful.Server
 	s.RegisterGetAction(ApiGetBalanceByAddr, service.GetBalanceByAddr)
 	s.RegisterGetAction(ApiGetBalanceByAsset, service.GetBalanceByAsset)
 	s.RegisterGetAction(ApiRestart, restartServer)
+	s.RegisterGetAction(ApiGetContractState, service.GetContractState)
+	s.RegisterGetAction(ApiGetManifest, service.GetContractManifest)
+	s.RegisterGetAction(ApiGetStorage, service.GetStorage)
+	s.RegisterGetAction(ApiGetApplicationLog, service.GetApplicationLog)
+	s.RegisterGetAction(ApiGetStateRoot, service.GetStateRoot)
+	s.RegisterGetAction(ApiGetNEP5Balances, service.GetNEP5Balances)
+	s.RegisterGetAction(ApiGetNEP11Balances, service.GetNEP11Balances)
+	s.RegisterGetAction(ApiGetNEP11Properties, service.GetNEP11Properties)
 
 	s.RegisterPostAction(ApiSendRawTransaction, sendRawTransaction)
+	s.RegisterPostAction(ApiFindStorage, findStorage)
+	s.RegisterPostAction(ApiListContracts, listContracts)
+	s.RegisterPostAction(ApiGetContractEvents, getContractEvents)
+	s.RegisterPostAction(ApiGetNEP5Transfers, getNEP5Transfers)
+	s.RegisterPostAction(ApiGetNEP11Transfers, getNEP11Transfers)
 
 	return s
 }
 
-func newWebSocketServer(port uint16, service *service.HttpService) *websocket.SocketServer {
+func newWebSocketServer(port, subscribePort uint16, service *service.HttpService) *websocket.SocketServer {
 	svrCfg := sw.Config{
 		ServePort: port,
 		Service:   service,
 	}
-	server := websocket.NewSocketServer(&svrCfg)
+	server := websocket.NewSocketServer(&svrCfg, subscribePort)
 	return server
 }
 
@@ -377,7 +513,9 @@ func printSyncState(db *blockchain.ChainStore, server server.Server) {
 
 func handleRunTimeEvents(et *events.Event) {
 	if et.Type == event.ETRunTimeNotify {
-		notifyInfo(et.Data.(datatype.StackItem))
+		args := et.Data.(*ns.NotifyEventArgs)
+		avmlog.Info("onRunTimeNotify:", args.ScriptHash.String(), args.EventName)
+		notifyInfo(args.Item)
 	} else if et.Type == event.ETRunTimeLog {
 		data := et.Data.(datatype.StackItem)
 		avmlog.Info("onRunTimeLog:", string(data.GetByteArray()))
@@ -18,25 +18,43 @@ import (
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/store"
 )
 
+// checkTransactionGas is the name the gas fee check is registered with.
+const checkTransactionGas = "CheckTransactionGas"
+
+// checkTransactionManifest is the name the deploy manifest check is
+// registered with.
+const checkTransactionManifest = "CheckTransactionManifest"
+
 type validator struct {
 	*mempool.Validator
 
 	systemAssetID common.Uint256
 	foundation    common.Uint168
 	spvService    *spv.Service
+	feeHelper     *mempool.FeeHelper
+	minTxFee      common.Fixed64
+	gasConfig     *params.GasConfig
+	forkConfig    *params.ForkConfig
 }
 
-func NewValidator(cfg *mempool.Config) *mempool.Validator {
+func NewValidator(cfg *mempool.Config, gasConfig *params.GasConfig,
+	forkConfig *params.ForkConfig) *mempool.Validator {
 	var val validator
 	val.Validator = mempool.NewValidator(cfg)
 	val.systemAssetID = cfg.ChainParams.ElaAssetId
 	val.foundation = cfg.ChainParams.Foundation
 	val.spvService = cfg.SpvService
+	val.feeHelper = cfg.FeeHelper
+	val.minTxFee = common.Fixed64(cfg.ChainParams.MinTransactionFee)
+	val.gasConfig = gasConfig
+	val.forkConfig = forkConfig
 
 	val.RegisterSanityFunc(mempool.FuncNames.CheckTransactionOutput, val.checkTransactionOutput)
 	val.RegisterSanityFunc(mempool.FuncNames.CheckTransactionPayload, val.checkTransactionPayload)
 	val.RegisterContextFunc(mempool.FuncNames.CheckTransactionSignature, val.checkTransactionSignature)
 	val.RegisterSanityFunc(mempool.FuncNames.CheckAttributeProgram, val.checkAttributeProgram)
+	val.RegisterContextFunc(checkTransactionGas, val.checkTransactionGas)
+	val.RegisterContextFunc(checkTransactionManifest, val.checkTransactionManifest)
 
 	return val.Validator
 }
@@ -56,13 +74,65 @@ func (v *validator) checkTransactionPayload(txn *side.Transaction) error {
 	case *side.PayloadRechargeToSideChain:
 	case *side.PayloadTransferCrossChainAsset:
 	case *types.PayloadDeploy:
+		if pld.Gas < 0 {
+			return errors.New("[ID CheckTransactionPayload] Invalide deploy gas.")
+		}
 	case *types.PayloadInvoke:
+		if pld.Gas < 0 {
+			return errors.New("[ID CheckTransactionPayload] Invalide invoke gas.")
+		}
 	default:
 		return errors.New("[ID CheckTransactionPayload] [txValidator],invalidate transaction payload type.")
 	}
 	return nil
 }
 
+// checkTransactionGas requires the fee of a deploy or invoke transaction to
+// cover its declared gas on top of the minimum transaction fee. It is a context
+// check, so the transactions of a block are checked with it before the block
+// is persisted, not only the ones entering the mempool.
+func (v *validator) checkTransactionGas(txn *side.Transaction) error {
+	var gas common.Fixed64
+	switch pld := txn.Payload.(type) {
+	case *types.PayloadDeploy:
+		gas = pld.Gas
+	case *types.PayloadInvoke:
+		gas = pld.Gas
+	default:
+		return nil
+	}
+	if blockchain.DefaultChain.BestChain.Height+1 < v.gasConfig.FeeHeight {
+		return nil
+	}
+	fee := v.feeHelper.GetTxFee(txn, v.systemAssetID)
+	gasFee := v.gasConfig.GasFee(gas)
+	if fee < v.minTxFee || fee-v.minTxFee < gasFee {
+		return fmt.Errorf("[checkTransactionGas] transaction fee %s does not cover the gas fee %s",
+			fee.String(), gasFee.String())
+	}
+	return nil
+}
+
+// checkTransactionManifest rejects the deploy payload carrying a manifest
+// below the height it is activated from, and checks the manifest above it.
+func (v *validator) checkTransactionManifest(txn *side.Transaction) error {
+	pld, ok := txn.Payload.(*types.PayloadDeploy)
+	if !ok || txn.PayloadVersion < types.DeployManifestPayloadVersion {
+		return nil
+	}
+	if blockchain.DefaultChain.BestChain.Height+1 < v.forkConfig.ManifestHeight {
+		return fmt.Errorf("[checkTransactionManifest] deploy payload version %d is not activated",
+			txn.PayloadVersion)
+	}
+	if pld.Manifest == nil {
+		return nil
+	}
+	if err := pld.Manifest.Validate(); err != nil {
+		return errors.New("[checkTransactionManifest] invalid deploy manifest, " + err.Error())
+	}
+	return nil
+}
+
 func checkAmountPrecise(amount common.Fixed64, precision byte, assetPrecision byte) bool {
 	return amount.IntValue()%int64(math.Pow10(int(assetPrecision-precision))) == 0
 }
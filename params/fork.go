@@ -0,0 +1,34 @@
+package params
+
+import "math"
+
+// ForkConfig defines the heights from which consensus changes are activated,
+// blocks below a height are executed and validated as they were before it.
+type ForkConfig struct {
+	// VMHeight is the first block in which Runtime.Serialize writes the
+	// canonical format, map keys are deduplicated, NEWSTRUCT pushes a struct
+	// and XTUCK inserts a single item.
+	VMHeight uint32
+	// ManifestHeight is the first block that may contain deploy payloads of
+	// the version carrying a contract manifest.
+	ManifestHeight uint32
+	// RuntimeHeight is the first block in which the Runtime and Blockchain
+	// syscalls and the asset expirations read the block being persisted
+	// instead of the best chain.
+	RuntimeHeight uint32
+}
+
+// MainNetForkConfig defines the fork heights of the main network, the forks
+// are not activated yet.
+var MainNetForkConfig = ForkConfig{
+	VMHeight:       math.MaxUint32,
+	ManifestHeight: math.MaxUint32,
+	RuntimeHeight:  math.MaxUint32,
+}
+
+// TestNetForkConfig defines the fork heights of the test network.
+var TestNetForkConfig = ForkConfig{
+	VMHeight:       math.MaxUint32,
+	ManifestHeight: math.MaxUint32,
+	RuntimeHeight:  math.MaxUint32,
+}
@@ -0,0 +1,51 @@
+package params
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+// GasConfig defines how deploy and invoke transactions pay for their gas. The
+// fee of a transaction must cover its declared gas at Price on top of the
+// minimum transaction fee, so the declared gas is paid with the fee whether or
+// not the execution uses all of it. Nothing is charged when the transaction is
+// persisted: the fee is already spent by then, the execution cannot consume
+// more than the declared gas and avm.FreeGas, and the gas it leaves is not
+// refunded.
+type GasConfig struct {
+	// Price is the fee in sela charged for one gas, which is 100000000 units
+	// of the declared gas.
+	Price common.Fixed64
+	// FeeHeight is the first block from which the fee must cover the gas.
+	FeeHeight uint32
+}
+
+// MainNetGasConfig defines the gas config of the main network, the gas fee is
+// not activated yet.
+var MainNetGasConfig = GasConfig{
+	Price:     100000000,
+	FeeHeight: math.MaxUint32,
+}
+
+// TestNetGasConfig defines the gas config of the test network.
+var TestNetGasConfig = GasConfig{
+	Price:     100000000,
+	FeeHeight: math.MaxUint32,
+}
+
+// GasFee returns the fee of gas at the price of the config, rounded up to the
+// next sela.
+func (cfg *GasConfig) GasFee(gas common.Fixed64) common.Fixed64 {
+	if gas <= 0 || cfg.Price <= 0 {
+		return 0
+	}
+	fee := new(big.Int).Mul(big.NewInt(int64(gas)), big.NewInt(int64(cfg.Price)))
+	fee.Add(fee, big.NewInt(100000000-1))
+	fee.Div(fee, big.NewInt(100000000))
+	if !fee.IsInt64() {
+		return math.MaxInt64
+	}
+	return common.Fixed64(fee.Int64())
+}
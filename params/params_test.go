@@ -36,3 +36,15 @@ func TestParams(t *testing.T) {
 	assert.Equal(t, "8NRxtbMKScEWzW8gmPDGUZ8LSzm688nkZZ", addr)
 	t.Log(addr)
 }
+
+func TestGasFee(t *testing.T) {
+	cfg := GasConfig{Price: 100000000}
+	assert.Equal(t, common.Fixed64(0), cfg.GasFee(0))
+	assert.Equal(t, common.Fixed64(0), cfg.GasFee(-1))
+	assert.Equal(t, common.Fixed64(12345), cfg.GasFee(12345))
+
+	cfg.Price = 1000
+	assert.Equal(t, common.Fixed64(1000), cfg.GasFee(100000000))
+	assert.Equal(t, common.Fixed64(1), cfg.GasFee(1))
+	assert.Equal(t, common.Fixed64(11), cfg.GasFee(1000001))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/store"
+)
+
+const (
+	// reindexCommand runs the reindex and exits without starting the node.
+	reindexCommand = "reindex-contracts"
+
+	reindexProgressInterval = 1000
+)
+
+type reindexConfig struct {
+	Enabled   bool
+	Offline   bool
+	Reference string
+}
+
+// parseReindexArgs parses the command line, the contract state is rebuilt
+// before the node starts with --reindex-contracts, and offline with the
+// reindex-contracts subcommand.
+func parseReindexArgs(args []string) (*reindexConfig, error) {
+	reindexCfg := new(reindexConfig)
+	if len(args) > 0 && args[0] == reindexCommand {
+		reindexCfg.Enabled = true
+		reindexCfg.Offline = true
+		args = args[1:]
+	}
+
+	flags := flag.NewFlagSet("sideNeo", flag.ContinueOnError)
+	enabled := flags.Bool("reindex-contracts", false,
+		"rebuild the contract state by executing all blocks from genesis before starting")
+	flags.StringVar(&reindexCfg.Reference, "reindex-reference", "",
+		"json file of the reference state roots, as returned by getstateroot")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if *enabled {
+		reindexCfg.Enabled = true
+	}
+	if reindexCfg.Reference != "" && !reindexCfg.Enabled {
+		return nil, errors.New("-reindex-reference requires a contract reindex")
+	}
+	return reindexCfg, nil
+}
+
+// loadReferenceRoots reads a json array of state roots, the entries are
+// matched by their Height and StateRoot fields.
+func loadReferenceRoots(path string) (map[uint32]common.Uint256, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var roots []struct {
+		Height    uint32
+		StateRoot string
+	}
+	if err := json.Unmarshal(data, &roots); err != nil {
+		return nil, errors.New("reference state roots json unmarshal error:" + err.Error())
+	}
+	reference := make(map[uint32]common.Uint256, len(roots))
+	for _, root := range roots {
+		data, err := common.HexStringToBytes(root.StateRoot)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := common.Uint256FromBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		reference[root.Height] = *hash
+	}
+	return reference, nil
+}
+
+// reindexContracts rebuilds the contract state, it is only run when asked for
+// on the command line since it executes every block again.
+func reindexContracts(ledgerStore *store.LedgerStore, reindexCfg *reindexConfig) error {
+	eladlog.Infof("Reindexing the contract state of blocks 0 to %d: the contracts, "+
+		"manifests, storage and storage trie, assets, accounts, application logs, "+
+		"events and token balances and transfers are wiped and rebuilt",
+		ledgerStore.GetHeight())
+	cfg := &store.ReindexConfig{
+		Progress: func(height, bestHeight uint32) {
+			if height%reindexProgressInterval == 0 || height == bestHeight {
+				eladlog.Infof("reindexed contract state %d/%d", height, bestHeight)
+			}
+		},
+	}
+	if reindexCfg.Reference != "" {
+		reference, err := loadReferenceRoots(reindexCfg.Reference)
+		if err != nil {
+			return err
+		}
+		eladlog.Infof("loaded %d reference state roots", len(reference))
+		cfg.Reference = reference
+	}
+	return ledgerStore.ReindexContracts(cfg)
+}
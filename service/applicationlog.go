@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+
+	. "github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+func (s *HttpServiceExtend) GetApplicationLog(param util.Params) (interface{}, error) {
+	str, ok := param.String("txid")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need txid")
+	}
+	hex, err := HexStringToBytes(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "txid is error hexString")
+	}
+	txID, err := Uint256FromBytes(BytesReverse(hex))
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "invalid txid")
+	}
+	key := append([]byte{byte(states.ST_ApplicationLog)}, txID.Bytes()...)
+	data, err := Store.Get(key)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "unknown application log "+str)
+	}
+	appLog := new(states.ApplicationLog)
+	if err := appLog.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, util.NewError(int(sideser.InternalError), err.Error())
+	}
+	return GetApplicationLogInfo(appLog), nil
+}
+
+func GetApplicationLogInfo(appLog *states.ApplicationLog) *ApplicationLogInfo {
+	stack := make([]interface{}, 0, len(appLog.Stack))
+	for _, item := range appLog.Stack {
+		stack = append(stack, stackItemInfo(item))
+	}
+	notifications := make([]NotificationInfo, 0, len(appLog.Notifications))
+	for _, notification := range appLog.Notifications {
+		notifications = append(notifications, notificationInfo(notification))
+	}
+	logs := make([]LogInfo, 0, len(appLog.Logs))
+	for _, l := range appLog.Logs {
+		logs = append(logs, LogInfo{
+			CodeHash: l.CodeHash.String(),
+			Message:  l.Message,
+		})
+	}
+	return &ApplicationLogInfo{
+		TxID:          sideser.ToReversedString(appLog.TxID),
+		VMState:       GetVMStateString(avm.VMState(appLog.VMState)),
+		Fault:         appLog.Fault,
+		GasConsumed:   appLog.GasConsumed.String(),
+		Stack:         stack,
+		Notifications: notifications,
+		Logs:          logs,
+	}
+}
+
+func GetVMStateString(state avm.VMState) string {
+	switch {
+	case state&avm.FAULT == avm.FAULT:
+		return "FAULT"
+	case state&avm.HALT == avm.HALT:
+		return "HALT"
+	case state&avm.BREAK == avm.BREAK:
+		return "BREAK"
+	}
+	return "NONE"
+}
+
+// stackItemInfo decodes a stack item serialized by the state reader. The raw
+// bytes are returned if the item can not be decoded, such as interop items.
+func stackItemInfo(data []byte) interface{} {
+	info, _ := decodeStackItem(data)
+	return info
+}
+
+func decodeStackItem(data []byte) (interface{}, datatype.StackItem) {
+	item, err := new(service.StateReader).DerializeStackItem(bytes.NewReader(data))
+	if err != nil {
+		return map[string]interface{}{
+			"type":  "Unknown",
+			"value": BytesToHexString(data),
+		}, nil
+	}
+	return service.StackItemToJson(item), item
+}
+
+func notificationInfo(notification *states.NotifyEvent) NotificationInfo {
+	state, item := decodeStackItem(notification.State)
+	info := NotificationInfo{
+		CodeHash: notification.CodeHash.String(),
+		State:    state,
+	}
+	if item != nil {
+		info.EventName = service.NotifyEventName(item)
+	}
+	return info
+}
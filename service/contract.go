@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+)
+
+const (
+	defaultFindStorageLimit = 100
+	maxFindStorageLimit     = 1000
+)
+
+func getContractState(codeHash *Uint168) (*states.ContractState, error) {
+	key := append([]byte{byte(sb.ST_Contract)}, params.UInt168ToUInt160(codeHash)...)
+	data, err := Store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	contractState := new(states.ContractState)
+	if err := contractState.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return contractState, nil
+}
+
+func GetContractStateInfo(codeHash *Uint168, contractState *states.ContractState) *ContractStateInfo {
+	parameters := make([]string, 0, len(contractState.Code.ParameterTypes))
+	for _, t := range contractState.Code.ParameterTypes {
+		parameters = append(parameters, t.String())
+	}
+	return &ContractStateInfo{
+		CodeHash:    codeHash.String(),
+		Code:        BytesToHexString(contractState.Code.Code),
+		Parameters:  parameters,
+		ReturnType:  contractState.Code.ReturnType.String(),
+		Name:        contractState.Name,
+		Version:     contractState.Version,
+		Author:      contractState.Author,
+		Email:       contractState.Email,
+		Description: contractState.Description,
+		ProgramHash: contractState.ProgramHash.String(),
+	}
+}
+
+func (s *HttpServiceExtend) GetContractState(param util.Params) (interface{}, error) {
+	str, ok := param.String("codehash")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need codehash")
+	}
+	codeHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	contractState, err := getContractState(codeHash)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "unknown contract "+str)
+	}
+	info := GetContractStateInfo(codeHash, contractState)
+	if index, err := getContractIndex(codeHash); err == nil {
+		info.Standards = index.Standards
+	}
+	if manifest, err := getContractManifest(codeHash); err == nil {
+		info.Manifest = GetContractManifestInfo(manifest)
+	}
+	return info, nil
+}
+
+func (s *HttpServiceExtend) GetStorage(param util.Params) (interface{}, error) {
+	str, ok := param.String("codehash")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need codehash")
+	}
+	codeHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	keyStr, ok := param.String("key")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need key")
+	}
+	key, err := HexStringToBytes(keyStr)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "key is error hexString")
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(sb.ST_Storage))
+	states.NewStorageKey(codeHash, key).Serialize(buf)
+	data, err := Store.Get(buf.Bytes())
+	if err != nil {
+		if err.Error() == "leveldb: not found" {
+			return nil, nil
+		}
+		return nil, util.NewError(int(sideser.InternalError), err.Error())
+	}
+	item := new(states.StorageItem)
+	if err := item.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, util.NewError(int(sideser.InternalError), err.Error())
+	}
+	return BytesToHexString(item.Value), nil
+}
+
+// FindStorage lists the storage of a contract whose keys start with prefix.
+// The cursor is the last key of the previous page, and the returned cursor is
+// empty when there are no more items.
+func (s *HttpServiceExtend) FindStorage(param util.Params) (interface{}, error) {
+	str, ok := param.String("codehash")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need codehash")
+	}
+	codeHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	keyPrefix, err := hexParam(param, "prefix")
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := hexParam(param, "cursor")
+	if err != nil {
+		return nil, err
+	}
+	limit := defaultFindStorageLimit
+	if l, ok := param.Int64("limit"); ok && l > 0 {
+		limit = int(l)
+	}
+	if limit > maxFindStorageLimit {
+		limit = maxFindStorageLimit
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(sb.ST_Storage))
+	codeHash.Serialize(buf)
+	storagePrefix := buf.Bytes()
+	prefix := append(append([]byte{}, storagePrefix...), keyPrefix...)
+
+	iter := Store.NewIterator(prefix)
+	defer iter.Release()
+
+	var next bool
+	if len(cursor) > 0 {
+		seekKey := append(append([]byte{}, storagePrefix...), cursor...)
+		next = iter.Seek(seekKey)
+		if next && bytes.Equal(iter.Key(), seekKey) {
+			next = iter.Next()
+		}
+	} else {
+		next = iter.Next()
+	}
+
+	items := make([]StorageInfo, 0)
+	var lastKey []byte
+	for ; next && len(items) < limit; next = iter.Next() {
+		item := new(states.StorageItem)
+		if err := item.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+			return nil, util.NewError(int(sideser.InternalError), err.Error())
+		}
+		lastKey = append([]byte{}, iter.Key()[len(storagePrefix):]...)
+		items = append(items, StorageInfo{
+			Key:   BytesToHexString(lastKey),
+			Value: BytesToHexString(item.Value),
+		})
+	}
+
+	nextCursor := ""
+	if next {
+		nextCursor = BytesToHexString(lastKey)
+	}
+	return map[string]interface{}{
+		"items":  items,
+		"cursor": nextCursor,
+	}, nil
+}
+
+func hexParam(param util.Params, name string) ([]byte, error) {
+	str, ok := param.String(name)
+	if !ok || str == "" {
+		return nil, nil
+	}
+	data, err := HexStringToBytes(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), fmt.Sprintf("%s is error hexString", name))
+	}
+	return data, nil
+}
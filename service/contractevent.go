@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+const (
+	defaultContractEventsLimit = 100
+	maxContractEventsLimit     = 1000
+
+	// maxContractEventsScan is the max number of blocks with events scanned
+	// by a request, the returned cursor continues the scan.
+	maxContractEventsScan = 10000
+)
+
+func GetContractEventInfo(event *states.ContractEvent) *ContractEventInfo {
+	state, _ := decodeStackItem(event.State)
+	return &ContractEventInfo{
+		CodeHash:  event.CodeHash.String(),
+		EventName: event.EventName,
+		Height:    event.Height,
+		TxID:      sideser.ToReversedString(event.TxID),
+		Index:     event.Index,
+		State:     state,
+	}
+}
+
+// GetContractEvents returns the events of a contract raised between two
+// heights in the order they were raised, optionally only the events with a
+// name. The cursor is the position of the last event of the previous page,
+// and the returned cursor is empty when the range is exhausted.
+func (s *HttpServiceExtend) GetContractEvents(param util.Params) (interface{}, error) {
+	str, ok := param.String("codehash")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need codehash")
+	}
+	codeHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	eventName, _ := param.String("eventname")
+	fromHeight := uint32(0)
+	if h, ok := param.Int64("fromheight"); ok && h > 0 {
+		fromHeight = uint32(h)
+	}
+	toHeight := uint32(math.MaxUint32)
+	if h, ok := param.Int64("toheight"); ok && h >= 0 && h < math.MaxUint32 {
+		toHeight = uint32(h)
+	}
+	// The cursor is the height and the sequence in the block of the last
+	// returned event.
+	var fromSeq uint32
+	if str, ok := param.String("cursor"); ok && str != "" {
+		data, err := common.HexStringToBytes(str)
+		if err != nil || len(data) != 8 {
+			return nil, util.NewError(int(sideser.InvalidParams), "invalid cursor")
+		}
+		height, seq := binary.BigEndian.Uint32(data), binary.BigEndian.Uint32(data[4:])
+		if seq == math.MaxUint32 {
+			if height == math.MaxUint32 {
+				return nil, util.NewError(int(sideser.InvalidParams), "invalid cursor")
+			}
+			height, seq = height+1, 0
+		} else {
+			seq++
+		}
+		if height > fromHeight {
+			fromHeight = height
+			fromSeq = seq
+		} else if height == fromHeight {
+			fromSeq = seq
+		}
+	}
+	limit := defaultContractEventsLimit
+	if l, ok := param.Int64("limit"); ok && l > 0 {
+		limit = int(l)
+	}
+	if limit > maxContractEventsLimit {
+		limit = maxContractEventsLimit
+	}
+
+	events := make([]*ContractEventInfo, 0)
+	cursor := func(height, seq uint32) string {
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint32(data, height)
+		binary.BigEndian.PutUint32(data[4:], seq)
+		return common.BytesToHexString(data)
+	}
+	result := func(next string) interface{} {
+		return map[string]interface{}{
+			"events": events,
+			"cursor": next,
+		}
+	}
+	if fromHeight > toHeight {
+		return result(""), nil
+	}
+
+	// Only the blocks with events have a bloom, so iterating the blooms skips
+	// the blocks without events and testing them skips most of the others.
+	blooms := Store.NewIterator([]byte{byte(states.ST_EventBloom)})
+	defer blooms.Release()
+	var lastHeight, lastSeq uint32
+	next := blooms.Seek(states.EventBloomKey(fromHeight))
+	for scanned := 0; next; next = blooms.Next() {
+		key := blooms.Key()
+		if len(key) != 5 {
+			continue
+		}
+		height := binary.BigEndian.Uint32(key[1:])
+		if height > toHeight {
+			break
+		}
+		if scanned == maxContractEventsScan {
+			return result(cursor(height-1, math.MaxUint32)), nil
+		}
+		scanned++
+		var bloom states.EventBloom
+		copy(bloom[:], blooms.Value())
+		if !bloom.Test(codeHash, eventName) {
+			continue
+		}
+
+		seq := uint32(0)
+		if height == fromHeight {
+			seq = fromSeq
+		}
+		prefix := states.ContractEventPrefix(height, codeHash)
+		iter := Store.NewIterator(prefix)
+		for ok := iter.Seek(states.ContractEventKey(height, codeHash, seq)); ok; ok = iter.Next() {
+			event := new(states.ContractEvent)
+			if err := event.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+				iter.Release()
+				return nil, util.NewError(int(sideser.InternalError), err.Error())
+			}
+			if eventName != "" && event.EventName != eventName {
+				continue
+			}
+			if len(events) == limit {
+				iter.Release()
+				return result(cursor(lastHeight, lastSeq)), nil
+			}
+			events = append(events, GetContractEventInfo(event))
+			lastHeight = height
+			lastSeq = binary.BigEndian.Uint32(iter.Key()[len(prefix):])
+		}
+		iter.Release()
+	}
+	return result(""), nil
+}
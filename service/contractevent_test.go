@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+var errNotFound = errors.New("leveldb: not found")
+
+// memDB is an in memory database for the services reading the store.
+type memDB struct {
+	database.Database
+	data map[string][]byte
+}
+
+func newMemDB() *memDB {
+	return &memDB{data: make(map[string][]byte)}
+}
+
+func (db *memDB) Get(key []byte) ([]byte, error) {
+	if value, ok := db.data[string(key)]; ok {
+		return value, nil
+	}
+	return nil, errNotFound
+}
+
+func (db *memDB) NewIterator(prefix []byte) database.Iterator {
+	iter := &memIterator{pos: -1}
+	for key := range db.data {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			iter.keys = append(iter.keys, key)
+		}
+	}
+	sort.Strings(iter.keys)
+	for _, key := range iter.keys {
+		iter.values = append(iter.values, db.data[key])
+	}
+	return iter
+}
+
+type memIterator struct {
+	database.Iterator
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func (iter *memIterator) Next() bool {
+	if iter.pos < len(iter.keys) {
+		iter.pos++
+	}
+	return iter.pos < len(iter.keys)
+}
+
+func (iter *memIterator) Seek(key []byte) bool {
+	iter.pos = sort.SearchStrings(iter.keys, string(key))
+	return iter.pos < len(iter.keys)
+}
+
+func (iter *memIterator) Key() []byte {
+	return []byte(iter.keys[iter.pos])
+}
+
+func (iter *memIterator) Value() []byte {
+	return iter.values[iter.pos]
+}
+
+func (iter *memIterator) Release() {}
+
+func putContractEvents(db *memDB, height uint32, codeHash *common.Uint168, names ...string) {
+	var bloom states.EventBloom
+	for seq, name := range names {
+		bloom.AddEvent(codeHash, name)
+		event := &states.ContractEvent{
+			CodeHash:  *codeHash,
+			Height:    height,
+			Index:     uint32(seq),
+			EventName: name,
+		}
+		db.data[string(states.ContractEventKey(height, codeHash, uint32(seq)))] = event.Bytes()
+	}
+	db.data[string(states.EventBloomKey(height))] = bloom[:]
+}
+
+func contractEventHeights(result interface{}) ([]uint32, string) {
+	page := result.(map[string]interface{})
+	var heights []uint32
+	for _, event := range page["events"].([]*ContractEventInfo) {
+		heights = append(heights, event.Height)
+	}
+	return heights, page["cursor"].(string)
+}
+
+func TestGetContractEvents(t *testing.T) {
+	contract := &common.Uint168{0x1c, 1}
+	other := &common.Uint168{0x1c, 2}
+	db := newMemDB()
+	putContractEvents(db, 1, contract, "transfer", "approve")
+	putContractEvents(db, 3, contract, "transfer")
+	putContractEvents(db, 5, contract, "transfer", "transfer")
+	// The bloom of height 4 only has the other contract, so the broken event
+	// stored for the contract at that height is never read.
+	putContractEvents(db, 4, other, "transfer")
+	db.data[string(states.ContractEventKey(4, contract, 0))] = []byte{0xff}
+	Store = db
+	defer func() { Store = nil }()
+
+	s := new(HttpServiceExtend)
+	param := util.Params{
+		"codehash":  common.BytesToHexString(contract[:]),
+		"eventname": "transfer",
+		"limit":     float64(2),
+	}
+	result, err := s.GetContractEvents(param)
+	assert.NoError(t, err)
+	heights, cursor := contractEventHeights(result)
+	assert.Equal(t, []uint32{1, 3}, heights)
+	assert.NotEqual(t, "", cursor)
+
+	param["cursor"] = cursor
+	result, err = s.GetContractEvents(param)
+	assert.NoError(t, err)
+	heights, cursor = contractEventHeights(result)
+	assert.Equal(t, []uint32{5, 5}, heights)
+	assert.Equal(t, "", cursor)
+
+	result, err = s.GetContractEvents(util.Params{
+		"codehash":   common.BytesToHexString(contract[:]),
+		"fromheight": float64(2),
+		"toheight":   float64(4),
+	})
+	assert.NoError(t, err)
+	heights, cursor = contractEventHeights(result)
+	assert.Equal(t, []uint32{3}, heights)
+	assert.Equal(t, "", cursor)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+const (
+	defaultListContractsLimit = 100
+	maxListContractsLimit     = 1000
+
+	// maxListContractsScan is the max number of contracts scanned by a
+	// request, the returned cursor continues the scan.
+	maxListContractsScan = 10000
+)
+
+func getContractIndex(codeHash *Uint168) (*states.ContractIndex, error) {
+	data, err := Store.Get(append([]byte{byte(states.IX_Contract)}, codeHash.Bytes()...))
+	if err != nil {
+		return nil, err
+	}
+	index := new(states.ContractIndex)
+	if err := index.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func GetContractIndexInfo(index *states.ContractIndex) *ContractIndexInfo {
+	info := &ContractIndexInfo{
+		CodeHash:  index.CodeHash.String(),
+		DeployTx:  sideser.ToReversedString(index.DeployTx),
+		Height:    index.Height,
+		Author:    index.Author,
+		Name:      index.Name,
+		Status:    index.Status.String(),
+		Standards: index.Standards,
+	}
+	if index.Status != states.ContractDeployed {
+		info.UpdateTx = sideser.ToReversedString(index.UpdateTx)
+		info.UpdateHeight = index.UpdateHeight
+	}
+	if index.Status == states.ContractMigrated {
+		info.MigratedTo = index.MigratedTo.String()
+	}
+	return info
+}
+
+// ListContracts enumerates the deployed contracts in code hash order. The
+// result can be filtered by author, a name substring, the lowest deploy
+// height, the contract status and a standard the contract implements. The
+// cursor is the code hash of the last contract scanned by the previous page,
+// and the returned cursor is empty when there are no more contracts. A page
+// may hold fewer contracts than the limit when the filters skip many of them.
+func (s *HttpServiceExtend) ListContracts(param util.Params) (interface{}, error) {
+	author, _ := param.String("author")
+	name, _ := param.String("name")
+	name = strings.ToLower(name)
+	fromHeight, _ := param.Int64("fromheight")
+	status, hasStatus := param.String("status")
+	standard, _ := param.String("standard")
+	cursor := ""
+	if str, ok := param.String("cursor"); ok && str != "" {
+		codeHash, err := ParseCodeHash(str)
+		if err != nil {
+			return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+		}
+		cursor = string(codeHash.Bytes())
+	}
+	limit := defaultListContractsLimit
+	if l, ok := param.Int64("limit"); ok && l > 0 {
+		limit = int(l)
+	}
+	if limit > maxListContractsLimit {
+		limit = maxListContractsLimit
+	}
+
+	prefix := []byte{byte(states.IX_Contract)}
+	iter := Store.NewIterator(prefix)
+	defer iter.Release()
+
+	var next bool
+	if len(cursor) > 0 {
+		seekKey := append(append([]byte{}, prefix...), cursor...)
+		next = iter.Seek(seekKey)
+		if next && bytes.Equal(iter.Key(), seekKey) {
+			next = iter.Next()
+		}
+	} else {
+		next = iter.Next()
+	}
+
+	contracts := make([]*ContractIndexInfo, 0)
+	var lastHash *Uint168
+	scanned := 0
+	for ; next && len(contracts) < limit && scanned < maxListContractsScan; next = iter.Next() {
+		scanned++
+		index := new(states.ContractIndex)
+		if err := index.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+			return nil, util.NewError(int(sideser.InternalError), err.Error())
+		}
+		codeHash := index.CodeHash
+		lastHash = &codeHash
+		if author != "" && index.Author != author {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(index.Name), name) {
+			continue
+		}
+		if int64(index.Height) < fromHeight {
+			continue
+		}
+		if hasStatus && status != "" && index.Status.String() != status {
+			continue
+		}
+		if standard != "" && !hasStandard(index, standard) {
+			continue
+		}
+		contracts = append(contracts, GetContractIndexInfo(index))
+	}
+
+	nextCursor := ""
+	if next && lastHash != nil {
+		nextCursor = lastHash.String()
+	}
+	return map[string]interface{}{
+		"contracts": contracts,
+		"cursor":    nextCursor,
+	}, nil
+}
+
+func hasStandard(index *states.ContractIndex, standard string) bool {
+	for _, s := range index.Standards {
+		if s == standard {
+			return true
+		}
+	}
+	return false
+}
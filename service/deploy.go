@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/artifact"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+)
+
+// ForkConfig is the fork config of the network the node runs on.
+var ForkConfig *params.ForkConfig
+
+// BuildDeployTransaction builds the unsigned deploy transaction of a compiled
+// contract from the hex of its .avm, its .abi.json as an object or a string
+// and the metadata of the contract. The gas is a decimal string and the
+// program hash the address of the deployer. The abi is attached as the
+// manifest when manifest is true, or by default once the next block may carry
+// manifests.
+func (s *HttpServiceExtend) BuildDeployTransaction(param util.Params) (interface{}, error) {
+	code, err := hexParam(param, "code")
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, util.NewError(int(sideser.InvalidParams), "need code")
+	}
+	var abiData []byte
+	switch abi := param["abi"].(type) {
+	case string:
+		abiData = []byte(abi)
+	case map[string]interface{}:
+		abiData, _ = json.Marshal(abi)
+	default:
+		return nil, util.NewError(int(sideser.InvalidParams), "need abi")
+	}
+	abi, err := artifact.ParseABI(abiData)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	contract, err := artifact.New(code, abi)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+
+	metadata := new(artifact.Metadata)
+	metadata.Name, _ = param.String("name")
+	metadata.Version, _ = param.String("version")
+	metadata.Author, _ = param.String("author")
+	metadata.Email, _ = param.String("email")
+	metadata.Description, _ = param.String("description")
+	if str, ok := param.String("programhash"); ok && str != "" {
+		programHash, err := common.Uint168FromAddress(str)
+		if err != nil {
+			return nil, util.NewError(int(sideser.InvalidParams), "invalid programhash "+str)
+		}
+		metadata.ProgramHash = *programHash
+	}
+	if str, ok := param.String("gas"); ok && str != "" {
+		gas, err := common.StringToFixed64(str)
+		if err != nil {
+			return nil, util.NewError(int(sideser.InvalidParams), "invalid gas "+str)
+		}
+		metadata.Gas = *gas
+	}
+	if manifest, ok := param.Bool("manifest"); ok {
+		metadata.Manifest = manifest
+	} else if ForkConfig != nil {
+		metadata.Manifest = s.cfg.Chain.GetBestHeight()+1 >= ForkConfig.ManifestHeight
+	}
+
+	tx, err := contract.DeployTransaction(metadata)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	codeHash, _ := contract.CodeHash()
+	buf := new(bytes.Buffer)
+	if err := tx.Serialize(buf); err != nil {
+		return nil, util.NewError(int(sideser.InternalError), err.Error())
+	}
+	ret := map[string]interface{}{
+		"tx":       common.BytesToHexString(buf.Bytes()),
+		"txid":     sideser.ToReversedString(tx.Hash()),
+		"codehash": codeHash.String(),
+	}
+	if GasConfig != nil {
+		fee := GasConfig.GasFee(metadata.Gas)
+		ret["gas_fee"] = fee.String()
+	}
+	return ret, nil
+}
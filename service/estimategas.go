@@ -0,0 +1,140 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+const (
+	// gasMarginPercent is added to the consumed gas, so a change of the
+	// chain state between the estimate and the execution does not make the
+	// transaction run out of gas.
+	gasMarginPercent = 10
+
+	// maxEstimateGas is the gas budget of the estimating execution.
+	maxEstimateGas = 9999999 * 100000000
+)
+
+// GasConfig is the gas config of the network the node runs on.
+var GasConfig *params.GasConfig
+
+// EstimateGas executes a deploy or invoke transaction the way it is executed
+// when persisted and returns the gas to declare for it. The transaction is
+// either a raw unsigned transaction, or built from scripthash, operation and
+// params and witnessed by signers.
+func (s *HttpServiceExtend) EstimateGas(param util.Params) (interface{}, error) {
+	tx, err := estimateTransaction(param)
+	if err != nil {
+		return nil, err
+	}
+	var script []byte
+	switch payload := tx.Payload.(type) {
+	case *types.PayloadDeploy:
+		if payload.Code == nil {
+			return nil, util.NewError(int(sideser.InvalidParams), "deploy payload has no code")
+		}
+		script = payload.CreateScript()
+	case *types.PayloadInvoke:
+		script = payload.Code
+	default:
+		return nil, util.NewError(int(sideser.InvalidParams), "transaction is not a deploy or invoke transaction")
+	}
+
+	dbCache := blockchain.NewDBCache(Store)
+	stateMachine := service.NewStateMachine(dbCache, dbCache)
+	engine := avm.NewExecutionEngine(tx, new(avm.CryptoECDsa), avm.MAXSTEPS, Table, stateMachine,
+		maxEstimateGas, avm.Application, false)
+	engine.LoadScript(script, false)
+	err = engine.Execute()
+
+	ret := make(map[string]interface{})
+	ret["state"] = engine.GetState()
+	ret["descript"] = GetDescByVMState(engine.GetState())
+	consumed := common.Fixed64(engine.GetGasConsumed())
+	ret["gas_consumed"] = consumed.String()
+	if err == nil && engine.GetState()&avm.FAULT == avm.FAULT {
+		err = errors.New("contract execution failed")
+	}
+	if err != nil {
+		ret["error"] = err.Error()
+		return ret, nil
+	}
+	gas := estimateDeclaredGas(consumed)
+	ret["gas"] = gas.String()
+	if GasConfig != nil {
+		fee := GasConfig.GasFee(gas)
+		ret["gas_fee"] = fee.String()
+	}
+	return ret, nil
+}
+
+// estimateDeclaredGas adds the margin to the consumed gas and takes off the
+// gas every execution is given for free.
+func estimateDeclaredGas(consumed common.Fixed64) common.Fixed64 {
+	gas := (consumed*(100+gasMarginPercent) + 99) / 100
+	gas -= avm.FreeGas
+	if gas < 0 {
+		return 0
+	}
+	return gas
+}
+
+func estimateTransaction(param util.Params) (*side.Transaction, error) {
+	if str, ok := param.String("tx"); ok && str != "" {
+		data, err := common.HexStringToBytes(str)
+		if err != nil {
+			return nil, util.NewError(int(sideser.InvalidParams), "tx is error hexString")
+		}
+		tx := new(side.Transaction)
+		if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+			return nil, util.NewError(int(sideser.InvalidParams), "invalid transaction: "+err.Error())
+		}
+		return tx, nil
+	}
+
+	script, err := buildInvokeScript(param)
+	if err != nil {
+		return nil, err
+	}
+	str, _ := param.String("scripthash")
+	codeHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	payload := &types.PayloadInvoke{CodeHash: *codeHash, Code: script}
+	tx := &side.Transaction{
+		TxType:     side.Invoke,
+		Payload:    payload,
+		Attributes: []*side.Attribute{},
+		Inputs:     []*side.Input{},
+		Outputs:    []*side.Output{},
+		Programs:   []*side.Program{},
+	}
+	signers, ok := ArrayString(param["signers"])
+	if param["signers"] != nil && !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "signers should be an array of addresses")
+	}
+	for i, signer := range signers {
+		programHash, err := common.Uint168FromAddress(signer)
+		if err != nil {
+			return nil, util.NewError(int(sideser.InvalidParams), "invalid signer "+signer)
+		}
+		if i == 0 {
+			payload.ProgramHash = *programHash
+		}
+		tx.Attributes = append(tx.Attributes, &side.Attribute{Usage: side.Script, Data: programHash.Bytes()})
+	}
+	return tx, nil
+}
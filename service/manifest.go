@@ -0,0 +1,300 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+func getContractManifest(codeHash *common.Uint168) (*types.ContractManifest, error) {
+	data, err := Store.Get(states.ContractManifestKey(codeHash))
+	if err != nil {
+		return nil, err
+	}
+	manifest := new(types.ContractManifest)
+	if err := manifest.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func getManifestParameterInfos(parameters []types.ManifestParameter) []ManifestParameterInfo {
+	infos := make([]ManifestParameterInfo, 0, len(parameters))
+	for _, parameter := range parameters {
+		infos = append(infos, ManifestParameterInfo{
+			Name: parameter.Name,
+			Type: parameter.Type.String(),
+		})
+	}
+	return infos
+}
+
+func GetContractManifestInfo(manifest *types.ContractManifest) *ContractManifestInfo {
+	info := &ContractManifestInfo{
+		Methods:     make([]ManifestMethodInfo, 0, len(manifest.Methods)),
+		Events:      make([]ManifestEventInfo, 0, len(manifest.Events)),
+		Standards:   manifest.Standards,
+		Permissions: make([]ManifestPermissionInfo, 0, len(manifest.Permissions)),
+	}
+	for _, method := range manifest.Methods {
+		info.Methods = append(info.Methods, ManifestMethodInfo{
+			Name:       method.Name,
+			Parameters: getManifestParameterInfos(method.Parameters),
+			ReturnType: method.ReturnType.String(),
+		})
+	}
+	for _, event := range manifest.Events {
+		info.Events = append(info.Events, ManifestEventInfo{
+			Name:       event.Name,
+			Parameters: getManifestParameterInfos(event.Parameters),
+		})
+	}
+	for _, permission := range manifest.Permissions {
+		codeHash := "*"
+		if permission.Contract != (common.Uint168{}) {
+			codeHash = permission.Contract.String()
+		}
+		info.Permissions = append(info.Permissions, ManifestPermissionInfo{
+			Contract: codeHash,
+			Methods:  permission.Methods,
+		})
+	}
+	return info
+}
+
+// invokeMethod returns the method of the manifest of the invoked contract the
+// invocation calls, or nil if the contract has no manifest or the manifest
+// does not declare the operation.
+func invokeMethod(param util.Params) *types.ManifestMethod {
+	operation, ok := param.String("operation")
+	if !ok || operation == "" {
+		return nil
+	}
+	str, _ := param.String("scripthash")
+	codeHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil
+	}
+	manifest, err := getContractManifest(codeHash)
+	if err != nil {
+		return nil
+	}
+	return manifest.Method(operation)
+}
+
+// resultDecoder returns how the result of an invocation is decoded, an
+// explicit returntype wins over the return type the manifest declares.
+func resultDecoder(param util.Params) func(datatype.StackItem) interface{} {
+	returnType, ok := param.String("returntype")
+	if !ok {
+		if method := invokeMethod(param); method != nil {
+			return func(item datatype.StackItem) interface{} {
+				return getManifestResult(item, method.ReturnType)
+			}
+		}
+		returnType = "Void"
+	}
+	return func(item datatype.StackItem) interface{} {
+		return getResult(item, returnType)
+	}
+}
+
+func getManifestResult(item datatype.StackItem, returnType contract.ContractParameterType) interface{} {
+	switch returnType {
+	case contract.Boolean:
+		return item.GetBoolean()
+	case contract.Integer:
+		return item.GetBigInteger()
+	case contract.String:
+		return string(item.GetByteArray())
+	case contract.ByteArray, contract.Signature, contract.PublicKey, contract.Hash160, contract.Hash256,
+		contract.Hash168:
+		return common.BytesToHexString(item.GetByteArray())
+	}
+	return service.StackItemToJson(item)
+}
+
+// emitManifestArgs pushes the arguments of a declared method as the array the
+// contract is invoked with. The arguments are checked against the declared
+// parameters, an argument is either a plain json value or an object with a
+// type and a value. An only argument of type Array is taken as the arguments
+// array itself, as clients unaware of manifests pass it.
+func emitManifestArgs(builder *avm.ParamsBuilder, method *types.ManifestMethod, params interface{}) error {
+	args, _ := params.([]interface{})
+	if len(args) == 1 && (len(method.Parameters) != 1 || method.Parameters[0].Type != contract.Array) {
+		if arg, ok := args[0].(map[string]interface{}); ok && arg["type"] == "Array" {
+			if list, ok := arg["value"].([]interface{}); ok {
+				args = list
+			}
+		}
+	}
+	if len(args) != len(method.Parameters) {
+		return fmt.Errorf("%s takes %d parameters, got %d", method.Name, len(method.Parameters), len(args))
+	}
+	for i := len(args) - 1; i >= 0; i-- {
+		if err := emitManifestArg(builder, method.Parameters[i].Type, args[i]); err != nil {
+			return fmt.Errorf("parameter %s of %s: %s", method.Parameters[i].Name, method.Name, err)
+		}
+	}
+	builder.EmitPushInteger(int64(len(args)))
+	builder.Emit(avm.PACK)
+	return nil
+}
+
+func emitManifestArg(builder *avm.ParamsBuilder, t contract.ContractParameterType, arg interface{}) error {
+	if typed, ok := arg.(map[string]interface{}); ok {
+		name, _ := typed["type"].(string)
+		argType, ok := contract.ParameterTypeMap[name]
+		if !ok || argType == contract.Void {
+			return fmt.Errorf("unknown type %q", name)
+		}
+		if t != contract.Object && argType != t {
+			return fmt.Errorf("expects %s, got %s", t, name)
+		}
+		t, arg = argType, typed["value"]
+	}
+	if t == contract.Object {
+		switch arg.(type) {
+		case bool:
+			t = contract.Boolean
+		case float64:
+			t = contract.Integer
+		case string:
+			t = contract.String
+		case []interface{}:
+			t = contract.Array
+		default:
+			return errors.New("unsupported value")
+		}
+	}
+
+	switch t {
+	case contract.Boolean:
+		value, ok := arg.(bool)
+		if !ok {
+			return errors.New("expects a boolean")
+		}
+		builder.EmitPushBool(value)
+	case contract.Integer:
+		value, err := manifestInteger(arg)
+		if err != nil {
+			return err
+		}
+		if value.IsInt64() {
+			builder.EmitPushInteger(value.Int64())
+		} else {
+			builder.EmitPushByteArray(service.BigIntToBytes(value))
+		}
+	case contract.String:
+		value, ok := arg.(string)
+		if !ok {
+			return errors.New("expects a string")
+		}
+		builder.EmitPushByteArray([]byte(value))
+	case contract.ByteArray, contract.Signature, contract.PublicKey, contract.Hash160, contract.Hash256,
+		contract.Hash168:
+		value, err := manifestBytes(t, arg)
+		if err != nil {
+			return err
+		}
+		builder.EmitPushByteArray(value)
+	case contract.Array:
+		list, ok := arg.([]interface{})
+		if !ok {
+			return errors.New("expects an array")
+		}
+		for i := len(list) - 1; i >= 0; i-- {
+			if err := emitManifestArg(builder, contract.Object, list[i]); err != nil {
+				return err
+			}
+		}
+		builder.EmitPushInteger(int64(len(list)))
+		builder.Emit(avm.PACK)
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	return nil
+}
+
+// manifestInteger accepts an integral json number or a decimal string, the
+// string form keeps the precision of values json numbers lose.
+func manifestInteger(arg interface{}) (*big.Int, error) {
+	switch value := arg.(type) {
+	case float64:
+		if value != math.Trunc(value) || math.Abs(value) > 1<<53 {
+			return nil, errors.New("expects an integer")
+		}
+		return big.NewInt(int64(value)), nil
+	case string:
+		if integer, ok := new(big.Int).SetString(value, 10); ok {
+			return integer, nil
+		}
+	}
+	return nil, errors.New("expects an integer")
+}
+
+// manifestBytes decodes a hex string and checks its length against the type,
+// a Hash160 or a Hash168 may also be given as an address.
+func manifestBytes(t contract.ContractParameterType, arg interface{}) ([]byte, error) {
+	str, ok := arg.(string)
+	if !ok {
+		return nil, fmt.Errorf("expects a %s hex string", t)
+	}
+	if t == contract.Hash160 || t == contract.Hash168 {
+		if programHash, err := common.Uint168FromAddress(str); err == nil {
+			if t == contract.Hash160 {
+				return programHash.Bytes()[1:], nil
+			}
+			return programHash.Bytes(), nil
+		}
+	}
+	data, err := common.HexStringToBytes(str)
+	if err != nil {
+		return nil, fmt.Errorf("expects a %s hex string", t)
+	}
+	size := map[contract.ContractParameterType]int{
+		contract.Signature: 64,
+		contract.PublicKey: 33,
+		contract.Hash160:   20,
+		contract.Hash256:   32,
+		contract.Hash168:   21,
+	}
+	if t == contract.Hash160 && len(data) == 21 {
+		data = data[1:]
+	}
+	if n, ok := size[t]; ok && len(data) != n {
+		return nil, fmt.Errorf("expects %d bytes, got %d", n, len(data))
+	}
+	return data, nil
+}
+
+// GetContractManifest returns the manifest a contract was deployed with.
+func (s *HttpServiceExtend) GetContractManifest(param util.Params) (interface{}, error) {
+	str, ok := param.String("codehash")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need codehash")
+	}
+	codeHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	manifest, err := getContractManifest(codeHash)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "no manifest for contract "+str)
+	}
+	return GetContractManifestInfo(manifest), nil
+}
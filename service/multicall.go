@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/storage"
+)
+
+// maxMultiCalls limits the number of calls of one invokemulti request.
+const maxMultiCalls = 256
+
+// maxSnapshotRetries is how many times invokemulti re-executes the calls when
+// the contract state changes while they are running, the request fails if
+// the calls never run against one state.
+const maxSnapshotRetries = 3
+
+// contractStateKey holds the height and the hash of the block the contract
+// state was persisted with last, it is written in the batch of every block
+// and of every rollback.
+var contractStateKey = []byte{byte(states.SYS_ContractState)}
+
+// InvokeMulti executes a list of read-only contract calls against the
+// contract state of one block and returns the state, gas and result of each
+// call.
+func (s *HttpServiceExtend) InvokeMulti(param util.Params) (interface{}, error) {
+	calls, ok := param["calls"].([]interface{})
+	if !ok || len(calls) == 0 {
+		return nil, util.NewError(int(sideser.InvalidParams), "need calls in an array!")
+	}
+	if len(calls) > maxMultiCalls {
+		return nil, util.NewError(int(sideser.InvalidParams),
+			fmt.Sprintf("too many calls, the limit is %d", maxMultiCalls))
+	}
+	failFast, _ := param.Bool("failfast")
+
+	scripts := make([][]byte, len(calls))
+	decoders := make([]func(datatype.StackItem) interface{}, len(calls))
+	for i, call := range calls {
+		object, ok := call.(map[string]interface{})
+		if !ok {
+			return nil, util.NewError(int(sideser.InvalidParams), fmt.Sprintf("invalid call at index %d", i))
+		}
+		callParam := util.Params(object)
+		script, err := buildInvokeScript(callParam)
+		if err != nil {
+			return nil, err
+		}
+		scripts[i] = script
+		decoders[i] = resultDecoder(callParam)
+	}
+
+	for retry := 0; retry <= maxSnapshotRetries; retry++ {
+		before, err := Store.Get(contractStateKey)
+		if err != nil {
+			return nil, util.NewError(int(sideser.InternalError), "contract state not found")
+		}
+		results := s.invokeCalls(scripts, decoders, failFast)
+		// any block persisted or rolled back meanwhile rewrote the key
+		after, err := Store.Get(contractStateKey)
+		if err != nil || !bytes.Equal(before, after) {
+			continue
+		}
+		reader := bytes.NewReader(before)
+		height, err := common.ReadUint32(reader)
+		if err != nil {
+			return nil, util.NewError(int(sideser.InternalError), err.Error())
+		}
+		var hash common.Uint256
+		if err := hash.Deserialize(reader); err != nil {
+			return nil, util.NewError(int(sideser.InternalError), err.Error())
+		}
+		return map[string]interface{}{
+			"height":    height,
+			"blockhash": sideser.ToReversedString(hash),
+			"results":   results,
+		}, nil
+	}
+	return nil, util.NewError(int(sideser.InternalError),
+		"the contract state changed while executing the calls, try again")
+}
+
+func (s *HttpServiceExtend) invokeCalls(scripts [][]byte, decoders []func(datatype.StackItem) interface{}, failFast bool) []interface{} {
+	dbCache := blockchain.NewDBCache(Store)
+	results := make([]interface{}, 0, len(scripts))
+	for i, script := range scripts {
+		// every call starts from the same state, writes of previous calls
+		// are discarded.
+		dbCache.RWSet = storage.NewRWSet()
+		engine := newEngine(dbCache, nil)
+		engine.LoadScript(script, false)
+		err := engine.Execute()
+
+		ret := invokeResult(engine, decoders[i])
+		if err != nil {
+			ret["error"] = err.Error()
+		}
+		results = append(results, ret)
+		if failFast && (err != nil || engine.GetState()&avm.FAULT == avm.FAULT) {
+			break
+		}
+	}
+	return results
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bytes"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+func GetNEP11TransferInfo(transfer *states.NEP11Transfer) *NEP11TransferInfo {
+	return &NEP11TransferInfo{
+		NEP5TransferInfo: *GetNEP5TransferInfo(&transfer.NEP5Transfer),
+		TokenID:          common.BytesToHexString(transfer.TokenID),
+	}
+}
+
+// GetNEP11Balances returns the NEP-11 tokens an account owns grouped by the
+// token contract.
+func (s *HttpServiceExtend) GetNEP11Balances(param util.Params) (interface{}, error) {
+	str, ok := param.String("address")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need address")
+	}
+	address, err := parseNEP5Address(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+
+	prefix := states.NEP11BalancePrefix(address)
+	iter := Store.NewIterator(prefix)
+	defer iter.Release()
+	balances := make([]*NEP11BalanceInfo, 0)
+	var last *NEP11BalanceInfo
+	for iter.Next() {
+		key := iter.Key()[len(prefix):]
+		if len(key) <= len(common.Uint168{}) {
+			continue
+		}
+		assetHash, err := common.Uint168FromBytes(key[:len(common.Uint168{})])
+		if err != nil {
+			continue
+		}
+		balance := new(states.NEP5Balance)
+		if err := balance.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+			return nil, util.NewError(int(sideser.InternalError), err.Error())
+		}
+		// the tokens of a contract are next to each other.
+		if last == nil || last.AssetHash != assetHash.String() {
+			token := GetNEP5TokenInfo(assetHash)
+			last = &NEP11BalanceInfo{
+				AssetHash: token.AssetHash,
+				Name:      token.Name,
+				Symbol:    token.Symbol,
+				Decimals:  token.Decimals,
+				Tokens:    make([]NEP11TokenBalanceInfo, 0),
+			}
+			balances = append(balances, last)
+		}
+		last.Tokens = append(last.Tokens, NEP11TokenBalanceInfo{
+			TokenID:          common.BytesToHexString(key[len(common.Uint168{}):]),
+			Amount:           balance.Balance.String(),
+			LastUpdatedBlock: balance.LastUpdatedHeight,
+		})
+	}
+	return map[string]interface{}{
+		"address":  str,
+		"balances": balances,
+	}, nil
+}
+
+// GetNEP11Transfers returns the NEP-11 transfers an account sent and received
+// between two heights. At most maxNEP5Transfers transfers are returned, the
+// returned next height is where the query continues if there are more.
+func (s *HttpServiceExtend) GetNEP11Transfers(param util.Params) (interface{}, error) {
+	query, err := parseTransfersQuery(param)
+	if err != nil {
+		return nil, err
+	}
+	sent := make([]*NEP11TransferInfo, 0)
+	received := make([]*NEP11TransferInfo, 0)
+	next, err := query.iterate(states.NEP11TransferPrefix(query.address), func(data []byte) error {
+		transfer := new(states.NEP11Transfer)
+		if err := transfer.Deserialize(bytes.NewReader(data)); err != nil {
+			return err
+		}
+		info := GetNEP11TransferInfo(transfer)
+		if bytes.Equal(states.NEP5Address(transfer.From), query.address) {
+			sent = append(sent, info)
+		}
+		if bytes.Equal(states.NEP5Address(transfer.To), query.address) {
+			received = append(received, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, util.NewError(int(sideser.InternalError), err.Error())
+	}
+	return query.result(sent, received, next), nil
+}
+
+// GetNEP11Properties returns the owners of a NEP-11 token and the properties
+// the token contract returns for it. Properties returned as a byte array are
+// returned as a string, the others as a stack item.
+func (s *HttpServiceExtend) GetNEP11Properties(param util.Params) (interface{}, error) {
+	str, ok := param.String("contract")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need contract")
+	}
+	assetHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	tokenID, err := hexParam(param, "tokenid")
+	if err != nil {
+		return nil, err
+	}
+	if len(tokenID) == 0 || len(tokenID) > states.MaxNEP11TokenIDSize {
+		return nil, util.NewError(int(sideser.InvalidParams), "invalid tokenid")
+	}
+
+	prefix := states.NEP11OwnerPrefix(assetHash, tokenID)
+	iter := Store.NewIterator(prefix)
+	defer iter.Release()
+	owners := make([]NEP11OwnerInfo, 0)
+	for iter.Next() {
+		balance := new(states.NEP5Balance)
+		if err := balance.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+			return nil, util.NewError(int(sideser.InternalError), err.Error())
+		}
+		owners = append(owners, NEP11OwnerInfo{
+			Address: common.BytesToHexString(iter.Key()[len(prefix):]),
+			Amount:  balance.Balance.String(),
+		})
+	}
+
+	var properties interface{}
+	if item := invokeToken(assetHash, "properties", tokenID); item != nil {
+		if _, ok := item.(*datatype.ByteArray); ok {
+			properties = string(item.GetByteArray())
+		} else {
+			properties = service.StackItemToJson(item)
+		}
+	}
+	return map[string]interface{}{
+		"assethash":  assetHash.String(),
+		"tokenid":    common.BytesToHexString(tokenID),
+		"owners":     owners,
+		"properties": properties,
+	}, nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	"github.com/elastos/Elastos.ELA.SideChain/events"
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+// maxNEP5Transfers is the number of transfers getnep5transfers returns before
+// it stops at the end of a block, the returned next height continues the
+// query.
+const maxNEP5Transfers = 1000
+
+// nep5Tokens caches the metadata of the NEP-5 tokens. A token is cached once
+// it answers all the metadata calls, and is dropped when it is migrated or
+// destroyed.
+var nep5Tokens = struct {
+	sync.RWMutex
+	tokens map[common.Uint168]*NEP5TokenInfo
+}{tokens: make(map[common.Uint168]*NEP5TokenInfo)}
+
+// GetNEP5TokenInfo returns the name, the symbol and the decimals of a NEP-5
+// or a NEP-11 token, they are read by invoking the token contract once.
+func GetNEP5TokenInfo(assetHash *common.Uint168) *NEP5TokenInfo {
+	nep5Tokens.RLock()
+	token, ok := nep5Tokens.tokens[*assetHash]
+	nep5Tokens.RUnlock()
+	if ok {
+		return token
+	}
+
+	token = &NEP5TokenInfo{AssetHash: assetHash.String()}
+	answered := true
+	if item := invokeToken(assetHash, "name"); item != nil {
+		token.Name = string(item.GetByteArray())
+	} else {
+		answered = false
+	}
+	if item := invokeToken(assetHash, "symbol"); item != nil {
+		token.Symbol = string(item.GetByteArray())
+	} else {
+		answered = false
+	}
+	if item := invokeToken(assetHash, "decimals"); item == nil {
+		answered = false
+	} else {
+		var decimals int64 = -1
+		switch item.(type) {
+		case *datatype.Integer:
+			if d := item.GetBigInteger(); d.IsInt64() {
+				decimals = d.Int64()
+			}
+		case *datatype.ByteArray:
+			if d := service.BytesToBigInt(item.GetByteArray()); d.IsInt64() {
+				decimals = d.Int64()
+			}
+		}
+		if decimals >= 0 && decimals <= math.MaxUint8 {
+			token.Decimals = uint8(decimals)
+		}
+	}
+
+	if answered {
+		nep5Tokens.Lock()
+		nep5Tokens.tokens[*assetHash] = token
+		nep5Tokens.Unlock()
+	}
+	return token
+}
+
+// OnContractChanged drops the cached metadata of the tokens migrated or
+// destroyed by a persisted transaction.
+func OnContractChanged(et *events.Event) {
+	if et.Type != event.ETContractChanged {
+		return
+	}
+	change := et.Data.(*service.ContractChange)
+	nep5Tokens.Lock()
+	delete(nep5Tokens.tokens, change.CodeHash)
+	nep5Tokens.Unlock()
+}
+
+// invokeToken calls a method of a token contract with byte array arguments,
+// it returns nil if the call fails.
+func invokeToken(assetHash *common.Uint168, operation string, args ...[]byte) datatype.StackItem {
+	buffer := new(bytes.Buffer)
+	builder := avm.NewParamsBuider(buffer)
+	for i := len(args) - 1; i >= 0; i-- {
+		builder.EmitPushByteArray(args[i])
+	}
+	builder.EmitPushInteger(int64(len(args)))
+	builder.Emit(avm.PACK)
+	builder.EmitPushByteArray([]byte(operation))
+	builder.EmitPushCall(common.BytesReverse(params.UInt168ToUInt160(assetHash)))
+
+	engine, err := RunScript(builder.Bytes())
+	if err != nil || engine.GetState()&avm.FAULT == avm.FAULT {
+		return nil
+	}
+	if engine.GetEvaluationStack().Count() == 0 {
+		return nil
+	}
+	return avm.PopStackItem(engine)
+}
+
+// parseNEP5Address accepts an address or the hex string of a 20 bytes hash160
+// or a 21 bytes hash168 and returns the address the NEP-5 index uses.
+func parseNEP5Address(str string) ([]byte, error) {
+	if programHash, err := common.Uint168FromAddress(str); err == nil {
+		return states.NEP5Address(programHash.Bytes()), nil
+	}
+	data, err := common.HexStringToBytes(str)
+	if err != nil || states.NEP5Address(data) == nil {
+		return nil, errors.New("invalid address " + str)
+	}
+	return states.NEP5Address(data), nil
+}
+
+func GetNEP5TransferInfo(transfer *states.NEP5Transfer) *NEP5TransferInfo {
+	return &NEP5TransferInfo{
+		AssetHash: transfer.AssetHash.String(),
+		From:      common.BytesToHexString(transfer.From),
+		To:        common.BytesToHexString(transfer.To),
+		Amount:    transfer.Amount.String(),
+		TxID:      sideser.ToReversedString(transfer.TxID),
+		Height:    transfer.Height,
+		Timestamp: transfer.Timestamp,
+		Index:     transfer.Index,
+	}
+}
+
+// GetNEP5Balances returns the balances of an account in the NEP-5 tokens it
+// has received or sent.
+func (s *HttpServiceExtend) GetNEP5Balances(param util.Params) (interface{}, error) {
+	str, ok := param.String("address")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need address")
+	}
+	address, err := parseNEP5Address(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+
+	prefix := states.NEP5BalancePrefix(address)
+	iter := Store.NewIterator(prefix)
+	defer iter.Release()
+	balances := make([]*NEP5BalanceInfo, 0)
+	for iter.Next() {
+		assetHash, err := common.Uint168FromBytes(iter.Key()[len(prefix):])
+		if err != nil {
+			continue
+		}
+		balance := new(states.NEP5Balance)
+		if err := balance.Deserialize(bytes.NewReader(iter.Value())); err != nil {
+			return nil, util.NewError(int(sideser.InternalError), err.Error())
+		}
+		token := GetNEP5TokenInfo(assetHash)
+		balances = append(balances, &NEP5BalanceInfo{
+			AssetHash:        token.AssetHash,
+			Name:             token.Name,
+			Symbol:           token.Symbol,
+			Decimals:         token.Decimals,
+			Amount:           balance.Balance.String(),
+			LastUpdatedBlock: balance.LastUpdatedHeight,
+		})
+	}
+	return map[string]interface{}{
+		"address":  str,
+		"balances": balances,
+	}, nil
+}
+
+// GetNEP5Transfers returns the NEP-5 transfers an account sent and received
+// between two heights. At most maxNEP5Transfers transfers are returned, the
+// returned next height is where the query continues if there are more.
+func (s *HttpServiceExtend) GetNEP5Transfers(param util.Params) (interface{}, error) {
+	query, err := parseTransfersQuery(param)
+	if err != nil {
+		return nil, err
+	}
+	sent := make([]*NEP5TransferInfo, 0)
+	received := make([]*NEP5TransferInfo, 0)
+	next, err := query.iterate(states.NEP5TransferPrefix(query.address), func(data []byte) error {
+		transfer := new(states.NEP5Transfer)
+		if err := transfer.Deserialize(bytes.NewReader(data)); err != nil {
+			return err
+		}
+		info := GetNEP5TransferInfo(transfer)
+		if bytes.Equal(states.NEP5Address(transfer.From), query.address) {
+			sent = append(sent, info)
+		}
+		if bytes.Equal(states.NEP5Address(transfer.To), query.address) {
+			received = append(received, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, util.NewError(int(sideser.InternalError), err.Error())
+	}
+	return query.result(sent, received, next), nil
+}
+
+// transfersQuery is the account and the height range of a transfers query.
+type transfersQuery struct {
+	str        string
+	address    []byte
+	fromHeight uint32
+	toHeight   uint32
+}
+
+func parseTransfersQuery(param util.Params) (*transfersQuery, error) {
+	str, ok := param.String("address")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need address")
+	}
+	address, err := parseNEP5Address(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	query := &transfersQuery{str: str, address: address, toHeight: math.MaxUint32}
+	if h, ok := param.Int64("from"); ok && h > 0 {
+		query.fromHeight = uint32(h)
+	}
+	if h, ok := param.Int64("to"); ok && h >= 0 && h < math.MaxUint32 {
+		query.toHeight = uint32(h)
+	}
+	return query, nil
+}
+
+// iterate calls fn with the transfers of the account under prefix in the
+// height range, it stops after maxNEP5Transfers transfers at the end of a
+// block and returns the height of the next block with transfers then.
+func (query *transfersQuery) iterate(prefix []byte, fn func(data []byte) error) (*uint32, error) {
+	if query.fromHeight > query.toHeight {
+		return nil, nil
+	}
+	iter := Store.NewIterator(prefix)
+	defer iter.Release()
+	seekKey := make([]byte, len(prefix)+8)
+	copy(seekKey, prefix)
+	binary.BigEndian.PutUint32(seekKey[len(prefix):], query.fromHeight)
+
+	count := 0
+	lastHeight := query.fromHeight
+	for ok := iter.Seek(seekKey); ok; ok = iter.Next() {
+		key := iter.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		height := binary.BigEndian.Uint32(key[len(prefix):])
+		if height > query.toHeight {
+			break
+		}
+		// a block is never split between two pages.
+		if count >= maxNEP5Transfers && height != lastHeight {
+			return &height, nil
+		}
+		lastHeight = height
+		if err := fn(iter.Value()); err != nil {
+			return nil, err
+		}
+		count++
+	}
+	return nil, nil
+}
+
+func (query *transfersQuery) result(sent, received interface{}, next *uint32) map[string]interface{} {
+	result := map[string]interface{}{
+		"address":  query.str,
+		"sent":     sent,
+		"received": received,
+	}
+	if next != nil {
+		result["next"] = *next
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain/events"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+func TestOnContractChanged(t *testing.T) {
+	token, other := common.Uint168{0x1c, 1}, common.Uint168{0x1c, 2}
+	nep5Tokens.tokens[token] = &NEP5TokenInfo{Name: "token"}
+	nep5Tokens.tokens[other] = &NEP5TokenInfo{Name: "other"}
+	defer func() {
+		delete(nep5Tokens.tokens, token)
+		delete(nep5Tokens.tokens, other)
+	}()
+
+	OnContractChanged(&events.Event{Type: event.ETContractChanged, Data: &service.ContractChange{
+		Action:   states.ContractDestroyed,
+		CodeHash: token,
+	}})
+	_, ok := nep5Tokens.tokens[token]
+	assert.False(t, ok)
+	_, ok = nep5Tokens.tokens[other]
+	assert.True(t, ok)
+}
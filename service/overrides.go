@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/interfaces"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/storage"
+	nt "github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+// ContractOverride replaces the code and injects storage of one contract for
+// the duration of a single what-if invocation.
+type ContractOverride struct {
+	Code    []byte
+	Storage map[string][]byte
+}
+
+// StateOverrides maps a contract code hash to the override applied to it.
+type StateOverrides map[common.Uint168]*ContractOverride
+
+// ParseStateOverrides reads the "overrides" rpc parameter, which has the form
+// {"<scripthash>": {"code": "<hex>", "storage": {"<hex key>": "<hex value>"}}}.
+func ParseStateOverrides(value interface{}) (StateOverrides, error) {
+	if value == nil {
+		return nil, nil
+	}
+	objects, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("overrides should be an object keyed by scripthash")
+	}
+	overrides := make(StateOverrides, len(objects))
+	for hashStr, v := range objects {
+		codeHash, err := ParseCodeHash(hashStr)
+		if err != nil {
+			return nil, err
+		}
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid override for %s", hashStr)
+		}
+		override := &ContractOverride{Storage: make(map[string][]byte)}
+		if code, ok := object["code"]; ok {
+			codeStr, ok := code.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid override code for %s", hashStr)
+			}
+			override.Code, err = common.HexStringToBytes(codeStr)
+			if err != nil || len(override.Code) == 0 {
+				return nil, fmt.Errorf("invalid override code for %s", hashStr)
+			}
+		}
+		if items, ok := object["storage"]; ok {
+			pairs, ok := items.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid override storage for %s", hashStr)
+			}
+			for k, v := range pairs {
+				key, err := common.HexStringToBytes(k)
+				if err != nil {
+					return nil, fmt.Errorf("invalid storage key %s", k)
+				}
+				valueStr, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid storage value of key %s", k)
+				}
+				value, err := common.HexStringToBytes(valueStr)
+				if err != nil {
+					return nil, fmt.Errorf("invalid storage value of key %s", k)
+				}
+				override.Storage[string(key)] = value
+			}
+		}
+		overrides[*codeHash] = override
+	}
+	return overrides, nil
+}
+
+// ParseCodeHash accepts a contract hash as 20 bytes hash160 or 21 bytes
+// hash168 hex string.
+func ParseCodeHash(str string) (*common.Uint168, error) {
+	data, err := common.HexStringToBytes(str)
+	if err != nil {
+		return nil, errors.New("invalid scripthash " + str)
+	}
+	switch len(data) {
+	case 20:
+		return common.Uint168FromBytes(append([]byte{params.PrefixSmartContract}, data...))
+	case 21:
+		return common.Uint168FromBytes(data)
+	}
+	return nil, errors.New("invalid scripthash " + str)
+}
+
+// Apply writes the overrides into the write set of dbCache, so they are only
+// visible to engines created with this cache and never committed.
+func (overrides StateOverrides) Apply(dbCache *blockchain.DBCache) {
+	writeSet := dbCache.GetWriteSet()
+	for codeHash, override := range overrides {
+		hash := codeHash
+		key := string(params.UInt168ToUInt160(&hash))
+		if override.Code != nil {
+			contractState := states.NewContractState()
+			item, err := dbCache.TryGet(sb.ST_Contract, key)
+			if err == nil && item != nil {
+				*contractState = *item.(*states.ContractState)
+			}
+			contractState.Code = &nt.FunctionCode{
+				Code:           override.Code,
+				ParameterTypes: contractState.Code.ParameterTypes,
+				ReturnType:     contractState.Code.ReturnType,
+			}
+			writeSet.WriteSet[key] = &storage.Write{
+				Prefix: sb.ST_Contract,
+				Key:    key,
+				Item:   contractState,
+			}
+		}
+		for k, v := range override.Storage {
+			storageKey := storage.KeyToStr(states.NewStorageKey(&hash, []byte(k)))
+			writeSet.WriteSet[storageKey] = &storage.Write{
+				Prefix: sb.ST_Storage,
+				Key:    storageKey,
+				Item:   states.NewStorageItem(v),
+			}
+		}
+	}
+}
+
+// aliases returns the overridden code mapped to the hash it replaces, so the
+// replacement code runs with the identity and storage of the original contract.
+func (overrides StateOverrides) aliases() map[string][]byte {
+	aliases := make(map[string][]byte)
+	for codeHash, override := range overrides {
+		if override.Code != nil {
+			hash := codeHash
+			aliases[string(override.Code)] = hash.Bytes()
+		}
+	}
+	return aliases
+}
+
+// overrideCrypto hashes overridden code to the hash of the contract it replaces.
+type overrideCrypto struct {
+	interfaces.ICrypto
+	aliases map[string][]byte
+}
+
+func (c *overrideCrypto) Hash168(data []byte) []byte {
+	if hash, ok := c.aliases[string(data)]; ok {
+		return hash
+	}
+	return c.ICrypto.Hash168(data)
+}
+
+// overrideCodeTable reads contract scripts through the overridden dbCache.
+type overrideCodeTable struct {
+	interfaces.IScriptTable
+	dbCache *blockchain.DBCache
+}
+
+func (table *overrideCodeTable) GetScript(codeHash []byte) []byte {
+	value, err := table.dbCache.TryGet(sb.ST_Contract, string(codeHash))
+	if err != nil || value == nil {
+		return nil
+	}
+	return value.(*states.ContractState).Code.Code
+}
+
+func (table *overrideCodeTable) GetTxReference(tx *interfaces.IDataContainer) (map[*types.Input]*types.Output, error) {
+	if table.IScriptTable == nil {
+		return nil, errors.New("script table is nil")
+	}
+	return table.IScriptTable.GetTxReference(tx)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bytes"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/trie"
+)
+
+// storageProofKey parses the codehash and key params into the key of the
+// storage trie.
+func storageProofKey(param util.Params) ([]byte, error) {
+	str, ok := param.String("codehash")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need codehash")
+	}
+	codeHash, err := ParseCodeHash(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	key, err := hexParam(param, "key")
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	states.NewStorageKey(codeHash, key).Serialize(buf)
+	return buf.Bytes(), nil
+}
+
+// GetProof returns the proof of a contract storage item against the storage
+// root of a block, the proof of a missing item proves it is absent.
+func (s *HttpServiceExtend) GetProof(param util.Params) (interface{}, error) {
+	key, err := storageProofKey(param)
+	if err != nil {
+		return nil, err
+	}
+	height, ok := param.Int64("height")
+	if !ok || height < 0 {
+		return nil, util.NewError(int(sideser.InvalidParams), "need height")
+	}
+	root, err := getStateRoot(uint32(height))
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "state root not found")
+	}
+	storageTrie := trie.New(root.StorageRoot, Store)
+	proof, err := storageTrie.Prove(key)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InternalError), err.Error())
+	}
+	value, err := trie.VerifyProof(root.StorageRoot, key, proof)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InternalError), err.Error())
+	}
+	nodes := make([]string, 0, len(proof))
+	for _, data := range proof {
+		nodes = append(nodes, common.BytesToHexString(data))
+	}
+	info := &StorageProofInfo{
+		Height:      root.Height,
+		StorageRoot: root.StorageRoot.String(),
+		Proof:       nodes,
+	}
+	if value != nil {
+		info.Value = common.BytesToHexString(value)
+		info.Exists = true
+	}
+	return info, nil
+}
+
+// VerifyProof checks a storage proof against a storage root and returns the
+// value it proves.
+func (s *HttpServiceExtend) VerifyProof(param util.Params) (interface{}, error) {
+	key, err := storageProofKey(param)
+	if err != nil {
+		return nil, err
+	}
+	str, ok := param.String("root")
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need root")
+	}
+	data, err := common.HexStringToBytes(str)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "root is error hexString")
+	}
+	root, err := common.Uint256FromBytes(data)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	items, ok := param["proof"].([]interface{})
+	if !ok {
+		return nil, util.NewError(int(sideser.InvalidParams), "need proof in an array!")
+	}
+	proof := make([][]byte, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, util.NewError(int(sideser.InvalidParams), "proof node is not a string")
+		}
+		node, err := common.HexStringToBytes(str)
+		if err != nil {
+			return nil, util.NewError(int(sideser.InvalidParams), "proof node is error hexString")
+		}
+		proof = append(proof, node)
+	}
+	value, err := trie.VerifyProof(*root, key, proof)
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	info := &ProofResultInfo{}
+	if value != nil {
+		info.Value = common.BytesToHexString(value)
+		info.Exists = true
+	}
+	return info, nil
+}
@@ -14,32 +14,46 @@ var Store database.Database
 var Table interfaces.IScriptTable
 
 func RunScript(script []byte) (*avm.ExecutionEngine, error) {
-	e := NewEngine()
+	return RunScriptWithOverrides(script, nil)
+}
+
+func RunScriptWithOverrides(script []byte, overrides StateOverrides) (*avm.ExecutionEngine, error) {
+	e := NewEngine(overrides)
 	e.LoadScript(script, false)
 	err := e.Execute()
 	return e, err
 }
 
 func RunGetPriceScript(script []byte) (*avm.ExecutionEngine, error) {
-	e := NewEngine()
+	e := NewEngine(nil)
 	e.LoadPriceOnlyScript(script)
 	err := e.Execute()
 	return e, err
 }
 
-func NewEngine() *avm.ExecutionEngine {
+func NewEngine(overrides StateOverrides) *avm.ExecutionEngine {
+	return newEngine(blockchain.NewDBCache(Store), overrides)
+}
+
+func newEngine(dbCache *blockchain.DBCache, overrides StateOverrides) *avm.ExecutionEngine {
 	container := types.Transaction{Inputs:[]*types.Input{}, Outputs:[]*types.Output{}}
-	dbCache := blockchain.NewDBCache(Store)
+	var crypto interfaces.ICrypto = new(avm.CryptoECDsa)
+	table := Table
+	if len(overrides) > 0 {
+		overrides.Apply(dbCache)
+		crypto = &overrideCrypto{ICrypto: crypto, aliases: overrides.aliases()}
+		table = &overrideCodeTable{IScriptTable: Table, dbCache: dbCache}
+	}
 	stateMachine := service.NewStateMachine(dbCache, dbCache)
 	e := avm.NewExecutionEngine(
 		&container,
-		new(avm.CryptoECDsa),
+		crypto,
 		avm.MAXSTEPS,
-		Table,
+		table,
 		stateMachine,
 		9999999 * 100000000,
 		avm.Application,
 		true,
 	)
 	return e
-}
\ No newline at end of file
+}
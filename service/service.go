@@ -187,6 +187,9 @@ func GetPayloadInfo(p side.Payload, pVersion byte) sideser.PayloadInfo {
 		obj.Description = object.Description
 		obj.ProgramHash = BytesToHexString(BytesReverse(object.ProgramHash.Bytes()))
 		obj.Gas = object.Gas.String()
+		if object.Manifest != nil {
+			obj.Manifest = GetContractManifestInfo(object.Manifest)
+		}
 		return obj
 	case *types.PayloadInvoke:
 		obj := new(InvokeInfo)
@@ -295,7 +298,12 @@ func (s *HttpServiceExtend) InvokeScript(param util.Params) (interface{}, error)
 		returntype = "Void"
 	}
 
-	engine, err := RunScript(code)
+	overrides, err := ParseStateOverrides(param["overrides"])
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+
+	engine, err := RunScriptWithOverrides(code, overrides)
 
 	var ret map[string]interface{}
 	ret = make(map[string]interface{})
@@ -311,12 +319,32 @@ func (s *HttpServiceExtend) InvokeScript(param util.Params) (interface{}, error)
 }
 
 func (s *HttpServiceExtend) InvokeFunction(param util.Params) (interface{}, error) {
+	script, err := buildInvokeScript(param)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := ParseStateOverrides(param["overrides"])
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+	}
+	engine, err := RunScriptWithOverrides(script, overrides)
+	if err != nil {
+		return false, nil
+	}
+	return invokeResult(engine, resultDecoder(param)), nil
+}
+
+func buildInvokeScript(param util.Params) ([]byte, error) {
 	buffer := new(bytes.Buffer)
 	paramBuilder := avm.NewParamsBuider(buffer)
 
 	args, ok := param["params"]
-	if ok {
-		argsData := args.([]interface{})
+	if method := invokeMethod(param); method != nil {
+		if err := emitManifestArgs(paramBuilder, method, args); err != nil {
+			return nil, util.NewError(int(sideser.InvalidParams), err.Error())
+		}
+	} else if ok {
+		argsData, _ := args.([]interface{})
 		if argsData != nil {
 			count := len(argsData)
 			for i := count - 1; i >= 0; i-- {
@@ -328,10 +356,6 @@ func (s *HttpServiceExtend) InvokeFunction(param util.Params) (interface{}, erro
 	if ok && operation != "" {
 		paramBuilder.EmitPushByteArray([]byte(operation))
 	}
-	returnType, ok := param.String("returntype")
-	if !ok {
-		returnType = "Void"
-	}
 
 	script, ok := param.String("scripthash")
 	if !ok {
@@ -350,10 +374,10 @@ func (s *HttpServiceExtend) InvokeFunction(param util.Params) (interface{}, erro
 	}
 	codeHashBytes = BytesReverse(codeHashBytes)
 	paramBuilder.EmitPushCall(codeHashBytes)
-	engine, err := RunScript(paramBuilder.Bytes())
-	if err != nil {
-		return false, nil
-	}
+	return paramBuilder.Bytes(), nil
+}
+
+func invokeResult(engine *avm.ExecutionEngine, decode func(datatype.StackItem) interface{}) map[string]interface{} {
 	var ret map[string]interface{}
 	ret = make(map[string]interface{})
 	ret["state"] = engine.GetState()
@@ -361,9 +385,9 @@ func (s *HttpServiceExtend) InvokeFunction(param util.Params) (interface{}, erro
 	value := Fixed64(engine.GetGasConsumed())
 	ret["gas_consumed"] = value.String()
 	if engine.GetEvaluationStack().Count() > 0 {
-		ret["result"] = getResult(avm.PopStackItem(engine), returnType)
+		ret["result"] = decode(avm.PopStackItem(engine))
 	}
-	return ret, nil
+	return ret
 }
 
 func paraseJsonToBytes(item map[string]interface{} , builder *avm.ParamsBuilder) error {
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"bytes"
+
+	"github.com/elastos/Elastos.ELA.Utility/http/util"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+func getStateRoot(height uint32) (*states.StateRoot, error) {
+	data, err := Store.Get(states.StateRootKey(height))
+	if err != nil {
+		return nil, err
+	}
+	root := new(states.StateRoot)
+	if err := root.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func (s *HttpServiceExtend) GetStateRoot(param util.Params) (interface{}, error) {
+	height, ok := param.Int64("height")
+	if !ok || height < 0 {
+		return nil, util.NewError(int(sideser.InvalidParams), "need height")
+	}
+	root, err := getStateRoot(uint32(height))
+	if err != nil {
+		return nil, util.NewError(int(sideser.InvalidParams), "state root not found")
+	}
+	return &StateRootInfo{
+		Height:      root.Height,
+		BlockHash:   sideser.ToReversedString(root.BlockHash),
+		ChangesRoot: root.ChangesRoot.String(),
+		StateRoot:   root.Root.String(),
+		StorageRoot: root.StorageRoot.String(),
+	}, nil
+}
@@ -18,6 +18,7 @@ type DeployInfo struct {
 	Description string
 	ProgramHash string
 	Gas         string
+	Manifest    *ContractManifestInfo `json:",omitempty"`
 }
 
 type InvokeInfo struct {
@@ -155,4 +156,167 @@ func GetAssetInfo(asset *types.Asset) *service.AssetInfo {
 		AssetType: int(asset.AssetType),
 		RecordType: int(asset.RecordType),
 	}
-}
\ No newline at end of file
+}
+
+type ContractStateInfo struct {
+	CodeHash    string
+	Code        string
+	Parameters  []string
+	ReturnType  string
+	Name        string
+	Version     string
+	Author      string
+	Email       string
+	Description string
+	ProgramHash string
+	Standards   []string              `json:",omitempty"`
+	Manifest    *ContractManifestInfo `json:",omitempty"`
+}
+
+type ManifestParameterInfo struct {
+	Name string
+	Type string
+}
+
+type ManifestMethodInfo struct {
+	Name       string
+	Parameters []ManifestParameterInfo
+	ReturnType string
+}
+
+type ManifestEventInfo struct {
+	Name       string
+	Parameters []ManifestParameterInfo
+}
+
+type ManifestPermissionInfo struct {
+	Contract string
+	Methods  []string
+}
+
+type ContractManifestInfo struct {
+	Methods     []ManifestMethodInfo
+	Events      []ManifestEventInfo
+	Standards   []string
+	Permissions []ManifestPermissionInfo
+}
+
+type StorageInfo struct {
+	Key   string
+	Value string
+}
+
+type ContractIndexInfo struct {
+	CodeHash     string
+	DeployTx     string
+	Height       uint32
+	Author       string
+	Name         string
+	Status       string
+	UpdateTx     string `json:",omitempty"`
+	UpdateHeight uint32 `json:",omitempty"`
+	MigratedTo   string   `json:",omitempty"`
+	Standards    []string `json:",omitempty"`
+}
+
+type NotificationInfo struct {
+	CodeHash  string
+	EventName string `json:",omitempty"`
+	State     interface{}
+}
+
+type ContractEventInfo struct {
+	CodeHash  string
+	EventName string `json:",omitempty"`
+	Height    uint32
+	TxID      string
+	Index     uint32
+	State     interface{}
+}
+
+type NEP5TokenInfo struct {
+	AssetHash string
+	Name      string
+	Symbol    string
+	Decimals  uint8
+}
+
+type NEP5BalanceInfo struct {
+	AssetHash        string
+	Name             string
+	Symbol           string
+	Decimals         uint8
+	Amount           string
+	LastUpdatedBlock uint32
+}
+
+type NEP5TransferInfo struct {
+	AssetHash string
+	From      string
+	To        string
+	Amount    string
+	TxID      string
+	Height    uint32
+	Timestamp uint32
+	Index     uint32
+}
+
+type NEP11TokenBalanceInfo struct {
+	TokenID          string
+	Amount           string
+	LastUpdatedBlock uint32
+}
+
+type NEP11BalanceInfo struct {
+	AssetHash string
+	Name      string
+	Symbol    string
+	Decimals  uint8
+	Tokens    []NEP11TokenBalanceInfo
+}
+
+type NEP11TransferInfo struct {
+	NEP5TransferInfo
+	TokenID string
+}
+
+type NEP11OwnerInfo struct {
+	Address string
+	Amount  string
+}
+
+type LogInfo struct {
+	CodeHash string
+	Message  string
+}
+
+type ApplicationLogInfo struct {
+	TxID          string
+	VMState       string
+	Fault         string `json:",omitempty"`
+	GasConsumed   string
+	Stack         []interface{}
+	Notifications []NotificationInfo
+	Logs          []LogInfo
+}
+
+type StateRootInfo struct {
+	Height      uint32
+	BlockHash   string
+	ChangesRoot string
+	StateRoot   string
+	StorageRoot string
+}
+
+type StorageProofInfo struct {
+	Height      uint32
+	StorageRoot string
+	Exists      bool
+	Value       string
+	Proof       []string
+}
+
+type ProofResultInfo struct {
+	Exists bool
+	Value  string
+}
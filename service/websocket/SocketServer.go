@@ -1,51 +1,82 @@
 package websocket
 
 import (
+	"encoding/json"
+
 	"github.com/elastos/Elastos.ELA.SideChain/events"
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
 	"github.com/elastos/Elastos.ELA.SideChain/service/websocket"
-	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.Utility/common"
 	"github.com/elastos/Elastos.ELA.Utility/http/util"
-	"encoding/json"
-	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/store"
+
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
-	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/store"
+	nt "github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
 )
 
 type SocketServer struct {
 	Server *websocket.Server
+
+	// Subscriptions serves the sessions subscribed to the events, it is nil
+	// if no subscription port is configured. The sessions of Server receive
+	// all the contract events either way.
+	Subscriptions *SubscriptionServer
 }
 
-func NewSocketServer(orgCfg *websocket.Config) *SocketServer {
+func NewSocketServer(orgCfg *websocket.Config, subscribePort uint16) *SocketServer {
 	s := websocket.NewServer(orgCfg)
 	server := &SocketServer{
-		s,
+		Server: s,
+	}
+	if subscribePort > 0 {
+		server.Subscriptions = NewSubscriptionServer(subscribePort)
 	}
 
 	return server
 }
 
+// BlockInfo is the message of the block topic.
+type BlockInfo struct {
+	Hash      string
+	Height    uint32
+	Timestamp uint32
+	TxCount   int
+}
+
+// TransactionInfo is the message of the transaction topic.
+type TransactionInfo struct {
+	TxHash    string
+	Height    uint32
+	TxType    side.TxType
+	Addresses []string
+}
+
 func (s *SocketServer) OnEvent(et *events.Event) {
-	var resp = &store.ResponseExt{
-		Action:   "",
-		Result:   false,
-		Desc:     "",
-		TxID:     "",
-		CodeHash: "",
-	}
-	var err error = nil
-	if et.Type == event.ETRunTimeNotify {
-		resp.Action = store.RunTime_Notify
-		resp.Desc = common.BytesToHexString(et.Data.(datatype.StackItem).GetByteArray())
-	} else if et.Type == event.ETRunTimeLog {
-		resp.Action = store.RunTime_Log
-		resp.Desc = string(et.Data.(datatype.StackItem).GetByteArray())
-	} else if et.Type == event.ETDeployTransaction || et.Type == event.ETInvokeTransaction {
-		resp = et.Data.(*store.ResponseExt)
+	s.broadcast(et)
+	if s.Subscriptions != nil {
+		s.publish(et)
 	}
+}
 
-	if resp.Action != "" {
-		s.response(resp, err)
+// broadcast sends the contract events to every session of the websocket port,
+// whether or not subscriptions are served on their own port.
+func (s *SocketServer) broadcast(et *events.Event) {
+	var resp *store.ResponseExt
+	switch et.Type {
+	case event.ETRunTimeNotify:
+		resp = notifyResponse(et.Data.(*service.NotifyEventArgs))
+	case event.ETRunTimeLog:
+		resp = logResponse(et.Data.(datatype.StackItem))
+	case event.ETDeployTransaction, event.ETInvokeTransaction:
+		resp = et.Data.(*store.ResponseExt)
+	default:
+		return
 	}
+	s.response(resp, nil)
 }
 
 func (s *SocketServer) response(resp *store.ResponseExt, err error) {
@@ -67,3 +98,128 @@ func (s *SocketServer) response(resp *store.ResponseExt, err error) {
 		session.Send(data)
 	})
 }
+
+// publish sends the events to the sessions whose subscriptions match them.
+func (s *SocketServer) publish(et *events.Event) {
+	switch et.Type {
+	case event.ETBlockPersisted:
+		block := et.Data.(*side.Block)
+		s.Subscriptions.publish(&message{
+			topic: TopicBlock,
+			data: &BlockInfo{
+				Hash:      sideser.ToReversedString(block.Hash()),
+				Height:    block.Height,
+				Timestamp: block.Timestamp,
+				TxCount:   len(block.Transactions),
+			},
+		})
+		for _, tx := range block.Transactions {
+			addresses := txAddresses(block, tx)
+			info := &TransactionInfo{
+				TxHash: sideser.ToReversedString(tx.Hash()),
+				Height: block.Height,
+				TxType: tx.TxType,
+			}
+			for _, programHash := range addresses {
+				if address, err := programHash.ToAddress(); err == nil {
+					info.Addresses = append(info.Addresses, address)
+				}
+			}
+			s.Subscriptions.publish(&message{
+				topic:     TopicTransaction,
+				addresses: addresses,
+				txID:      tx.Hash(),
+				data:      info,
+			})
+		}
+	case event.ETRunTimeNotify:
+		args := et.Data.(*service.NotifyEventArgs)
+		s.Subscriptions.publish(&message{
+			topic:     TopicNotification,
+			contract:  args.ScriptHash,
+			eventName: args.EventName,
+			txID:      args.TxID,
+			data:      notifyResponse(args),
+		})
+	case event.ETRunTimeLog:
+		s.Subscriptions.publish(&message{
+			topic: TopicLog,
+			data:  logResponse(et.Data.(datatype.StackItem)),
+		})
+	case event.ETDeployTransaction, event.ETInvokeTransaction:
+		resp := et.Data.(*store.ResponseExt)
+		msg := &message{topic: TopicReceipt, data: resp}
+		if txID, err := parseTxID(resp.TxHash); err == nil {
+			msg.txID = *txID
+		}
+		s.Subscriptions.publish(msg)
+	}
+}
+
+func notifyResponse(args *service.NotifyEventArgs) *store.ResponseExt {
+	return &store.ResponseExt{
+		Action:    store.RunTime_Notify,
+		Result:    true,
+		Desc:      service.StackItemToJson(args.Item),
+		TxID:      args.TxID.String(),
+		TxHash:    sideser.ToReversedString(args.TxID),
+		CodeHash:  args.ScriptHash.String(),
+		Height:    args.Height,
+		Index:     args.Index,
+		EventName: args.EventName,
+	}
+}
+
+func logResponse(item datatype.StackItem) *store.ResponseExt {
+	return &store.ResponseExt{
+		Action: store.RunTime_Log,
+		Desc:   string(item.GetByteArray()),
+	}
+}
+
+// txAddresses returns the program hashes a transaction touches, which are the
+// owners of its inputs and outputs and the caller of a contract transaction.
+func txAddresses(block *side.Block, tx *side.Transaction) []common.Uint168 {
+	seen := make(map[common.Uint168]bool)
+	var addresses []common.Uint168
+	add := func(programHash common.Uint168) {
+		if !seen[programHash] {
+			seen[programHash] = true
+			addresses = append(addresses, programHash)
+		}
+	}
+	for _, output := range tx.Outputs {
+		add(output.ProgramHash)
+	}
+	for _, input := range tx.Inputs {
+		if output := referencedOutput(block, input); output != nil {
+			add(output.ProgramHash)
+		}
+	}
+	switch payload := tx.Payload.(type) {
+	case *nt.PayloadDeploy:
+		add(payload.ProgramHash)
+	case *nt.PayloadInvoke:
+		add(payload.ProgramHash)
+	}
+	return addresses
+}
+
+// referencedOutput returns the output spent by the input, which may be an
+// output of a transaction in the same block.
+func referencedOutput(block *side.Block, input *side.Input) *side.Output {
+	var prev *side.Transaction
+	for _, tx := range block.Transactions {
+		if tx.Hash().IsEqual(input.Previous.TxID) {
+			prev = tx
+			break
+		}
+	}
+	if prev == nil && blockchain.DefaultChain != nil {
+		prev, _, _ = blockchain.DefaultChain.GetTransaction(input.Previous.TxID)
+	}
+	if prev == nil || int(input.Previous.Index) >= len(prev.Outputs) {
+		return nil
+	}
+	return prev.Outputs[input.Previous.Index]
+}
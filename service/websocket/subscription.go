@@ -0,0 +1,286 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	sv "github.com/elastos/Elastos.ELA.SideChain.NeoVM/service"
+)
+
+// Topics a session can subscribe to.
+const (
+	TopicBlock        = "block"
+	TopicTransaction  = "transaction"
+	TopicNotification = "notification"
+	TopicReceipt      = "receipt"
+	TopicLog          = "log"
+)
+
+const (
+	// MaxSubscriptions is the max number of subscriptions of a session.
+	MaxSubscriptions = 32
+
+	// MaxPendingMessages is the max number of messages queued for a session,
+	// a session that does not read its messages fast enough is closed.
+	MaxPendingMessages = 256
+)
+
+var (
+	ErrUnknownTopic         = errors.New("unknown topic")
+	ErrTooManySubscriptions = errors.New("too many subscriptions")
+	ErrUnknownSubscription  = errors.New("unknown subscription")
+)
+
+// SubscribeRequest is the filter of a subscription. Address applies to the
+// transaction topic, Contract and EventName to the notification topic and
+// TxID to the transaction, notification and receipt topics. An empty filter
+// field matches everything.
+type SubscribeRequest struct {
+	Topic     string
+	Address   string
+	Contract  string
+	EventName string
+	TxID      string
+}
+
+type filter struct {
+	topic     string
+	address   *common.Uint168
+	contract  *common.Uint168
+	eventName string
+	txID      *common.Uint256
+}
+
+func newFilter(req *SubscribeRequest) (*filter, error) {
+	f := &filter{topic: req.Topic, eventName: req.EventName}
+	switch req.Topic {
+	case TopicBlock, TopicTransaction, TopicNotification, TopicReceipt, TopicLog:
+	default:
+		return nil, ErrUnknownTopic
+	}
+	if req.Address != "" {
+		address, err := common.Uint168FromAddress(req.Address)
+		if err != nil {
+			return nil, errors.New("invalid address " + req.Address)
+		}
+		f.address = address
+	}
+	if req.Contract != "" {
+		contract, err := sv.ParseCodeHash(req.Contract)
+		if err != nil {
+			return nil, err
+		}
+		f.contract = contract
+	}
+	if req.TxID != "" {
+		txID, err := parseTxID(req.TxID)
+		if err != nil {
+			return nil, err
+		}
+		f.txID = txID
+	}
+	return f, nil
+}
+
+// parseTxID parses a txid in the reversed form the RPC prints it in, which is
+// the form of the TxHash of the published events.
+func parseTxID(str string) (*common.Uint256, error) {
+	data, err := common.HexStringToBytes(str)
+	if err != nil {
+		return nil, errors.New("invalid txid " + str)
+	}
+	txID, err := common.Uint256FromBytes(common.BytesReverse(data))
+	if err != nil {
+		return nil, errors.New("invalid txid " + str)
+	}
+	return txID, nil
+}
+
+// message is an event published to the sessions whose subscriptions match
+// it, the fields other than topic and data are only used for matching.
+type message struct {
+	topic     string
+	addresses []common.Uint168
+	contract  common.Uint168
+	eventName string
+	txID      common.Uint256
+	data      interface{}
+}
+
+func (f *filter) match(msg *message) bool {
+	if f.topic != msg.topic {
+		return false
+	}
+	if f.address != nil {
+		found := false
+		for _, address := range msg.addresses {
+			if address.IsEqual(*f.address) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.contract != nil && !f.contract.IsEqual(msg.contract) {
+		return false
+	}
+	if f.eventName != "" && f.eventName != msg.eventName {
+		return false
+	}
+	if f.txID != nil && !f.txID.IsEqual(msg.txID) {
+		return false
+	}
+	return true
+}
+
+// subscriber is the subscription state of a session. Messages are queued in
+// send and written by the session, the queue is bounded so a slow session can
+// not hold the messages of the others back.
+type subscriber struct {
+	mu      sync.Mutex
+	filters map[uint32]*filter
+	nextID  uint32
+	send    chan []byte
+	closed  bool
+}
+
+func newSubscriber() *subscriber {
+	return &subscriber{
+		filters: make(map[uint32]*filter),
+		nextID:  1,
+		send:    make(chan []byte, MaxPendingMessages),
+	}
+}
+
+func (s *subscriber) subscribe(req *SubscribeRequest) (uint32, error) {
+	f, err := newFilter(req)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.filters) >= MaxSubscriptions {
+		return 0, ErrTooManySubscriptions
+	}
+	id := s.nextID
+	s.nextID++
+	s.filters[id] = f
+	return id, nil
+}
+
+func (s *subscriber) unsubscribe(id uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.filters[id]; !ok {
+		return ErrUnknownSubscription
+	}
+	delete(s.filters, id)
+	return nil
+}
+
+// matches returns the ids of the subscriptions that match the message.
+func (s *subscriber) matches(msg *message) []uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []uint32
+	for id, f := range s.filters {
+		if f.match(msg) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// push queues data for the session, it returns false if the queue is full.
+func (s *subscriber) push(data []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return true
+	}
+	select {
+	case s.send <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.send)
+	}
+}
+
+// subscriptions keeps the subscribers of all sessions.
+type subscriptions struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{subscribers: make(map[*subscriber]struct{})}
+}
+
+func (s *subscriptions) add(sub *subscriber) {
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *subscriptions) remove(sub *subscriber) {
+	s.mu.Lock()
+	delete(s.subscribers, sub)
+	s.mu.Unlock()
+	sub.close()
+}
+
+// publish queues the message for every subscriber with a matching
+// subscription, subscribers whose queue is full are removed.
+func (s *subscriptions) publish(msg *message) {
+	s.mu.RLock()
+	var overflowed []*subscriber
+	for sub := range s.subscribers {
+		ids := sub.matches(msg)
+		if len(ids) == 0 {
+			continue
+		}
+		data, err := json.Marshal(&EventResponse{
+			Action:        "event",
+			Topic:         msg.topic,
+			Subscriptions: ids,
+			Result:        msg.data,
+		})
+		if err != nil {
+			log.Errorf("marshal %s event failed, %s", msg.topic, err)
+			continue
+		}
+		if !sub.push(data) {
+			overflowed = append(overflowed, sub)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, sub := range overflowed {
+		log.Warnf("websocket session is too slow, %d messages pending", MaxPendingMessages)
+		s.remove(sub)
+	}
+}
+
+// EventResponse is a message sent to a session for its subscriptions.
+type EventResponse struct {
+	Action        string
+	Topic         string
+	Subscriptions []uint32
+	Result        interface{}
+}
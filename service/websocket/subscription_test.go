@@ -0,0 +1,126 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.SideChain/events"
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/store"
+	nt "github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+func TestSubscriptionFilter(t *testing.T) {
+	contract := common.Uint168{0x1c, 0x01}
+	other := common.Uint168{0x1c, 0x02}
+
+	sub := newSubscriber()
+	_, err := sub.subscribe(&SubscribeRequest{Topic: "unknown"})
+	assert.Equal(t, ErrUnknownTopic, err)
+	all, err := sub.subscribe(&SubscribeRequest{Topic: TopicNotification})
+	assert.NoError(t, err)
+	transfers, err := sub.subscribe(&SubscribeRequest{
+		Topic:     TopicNotification,
+		Contract:  common.BytesToHexString(contract[:]),
+		EventName: "transfer",
+	})
+	assert.NoError(t, err)
+
+	msg := &message{topic: TopicNotification, contract: contract, eventName: "transfer"}
+	assert.Equal(t, []uint32{all, transfers}, sub.matches(msg))
+	msg.contract = other
+	assert.Equal(t, []uint32{all}, sub.matches(msg))
+	msg.topic = TopicLog
+	assert.Equal(t, 0, len(sub.matches(msg)))
+
+	assert.NoError(t, sub.unsubscribe(all))
+	assert.Equal(t, ErrUnknownSubscription, sub.unsubscribe(all))
+	for i := 1; i < MaxSubscriptions; i++ {
+		_, err = sub.subscribe(&SubscribeRequest{Topic: TopicBlock})
+		assert.NoError(t, err)
+	}
+	_, err = sub.subscribe(&SubscribeRequest{Topic: TopicBlock})
+	assert.Equal(t, ErrTooManySubscriptions, err)
+}
+
+func TestSubscriptionBackpressure(t *testing.T) {
+	subs := newSubscriptions()
+	slow, idle := newSubscriber(), newSubscriber()
+	slow.subscribe(&SubscribeRequest{Topic: TopicBlock})
+	idle.subscribe(&SubscribeRequest{Topic: TopicLog})
+	subs.add(slow)
+	subs.add(idle)
+
+	for i := 0; i < MaxPendingMessages; i++ {
+		subs.publish(&message{topic: TopicBlock})
+	}
+	assert.Equal(t, 2, len(subs.subscribers))
+	assert.Equal(t, 0, len(idle.send))
+
+	// The session is removed once its queue overflows, the others stay.
+	subs.publish(&message{topic: TopicBlock})
+	assert.Equal(t, 1, len(subs.subscribers))
+	_, ok := subs.subscribers[idle]
+	assert.True(t, ok)
+	assert.True(t, slow.closed)
+}
+
+func TestSubscriptionTxIDFilter(t *testing.T) {
+	block := &side.Block{}
+	for i := byte(0); i < 2; i++ {
+		block.Transactions = append(block.Transactions, &side.Transaction{
+			TxType:  side.Invoke,
+			Payload: &nt.PayloadInvoke{Code: []byte{i}},
+		})
+	}
+	txID := sideser.ToReversedString(block.Transactions[0].Hash())
+
+	server := &SocketServer{Subscriptions: NewSubscriptionServer(0)}
+	sub := newSubscriber()
+	server.Subscriptions.subscriptions.add(sub)
+	topics := []string{TopicTransaction, TopicNotification, TopicReceipt}
+	for _, topic := range topics {
+		req, _ := json.Marshal(&SubscriptionRequest{
+			Action:           "subscribe",
+			SubscribeRequest: SubscribeRequest{Topic: topic, TxID: txID},
+		})
+		assert.Equal(t, 0, handleRequest(sub, req).Error)
+	}
+
+	server.publish(&events.Event{Type: event.ETBlockPersisted, Data: block})
+	for _, tx := range block.Transactions {
+		server.publish(&events.Event{Type: event.ETRunTimeNotify, Data: &service.NotifyEventArgs{
+			TxID: tx.Hash(),
+			Item: datatype.NewByteArray([]byte("transfer")),
+		}})
+		server.publish(&events.Event{Type: event.ETInvokeTransaction, Data: &store.ResponseExt{
+			Action: store.INVOKE_TRANSACTION,
+			Result: true,
+			TxID:   tx.Hash().String(),
+			TxHash: sideser.ToReversedString(tx.Hash()),
+		}})
+	}
+
+	// Only the events of the filtered transaction are sent, their TxHash is
+	// the txid the way the filter takes it and TxID keeps its old form.
+	for _, topic := range topics {
+		var resp struct {
+			Topic  string
+			Result struct{ TxID, TxHash string }
+		}
+		assert.NoError(t, json.Unmarshal(<-sub.send, &resp))
+		assert.Equal(t, topic, resp.Topic)
+		assert.Equal(t, txID, resp.Result.TxHash)
+		if topic != TopicTransaction {
+			assert.Equal(t, block.Transactions[0].Hash().String(), resp.Result.TxID)
+		}
+	}
+	assert.Equal(t, 0, len(sub.send))
+}
@@ -0,0 +1,137 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
+)
+
+const (
+	// maxRequestSize is the max size of a message read from a session.
+	maxRequestSize = 4096
+
+	// writeWait is the time allowed to write a message to a session.
+	writeWait = 10 * time.Second
+)
+
+// SubscriptionRequest is a message read from a session. The subscribe action
+// adds a subscription and returns its id, the unsubscribe action removes the
+// subscription with Id.
+type SubscriptionRequest struct {
+	Action string
+	Id     uint32
+	SubscribeRequest
+}
+
+// SubscriptionResponse is the response to a subscription request.
+type SubscriptionResponse struct {
+	Action string
+	Error  int
+	Desc   string
+	Result interface{}
+}
+
+// SubscriptionServer serves the websocket sessions that subscribe to topics,
+// each session only receives the events matching its subscriptions.
+type SubscriptionServer struct {
+	server        *http.Server
+	upgrader      gws.Upgrader
+	subscriptions *subscriptions
+}
+
+func NewSubscriptionServer(port uint16) *SubscriptionServer {
+	s := &SubscriptionServer{
+		upgrader: gws.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		subscriptions: newSubscriptions(),
+	}
+	s.server = &http.Server{
+		Addr:    ":" + strconv.Itoa(int(port)),
+		Handler: http.HandlerFunc(s.serveSession),
+	}
+	return s
+}
+
+func (s *SubscriptionServer) Start() error {
+	err := s.server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func (s *SubscriptionServer) Stop() {
+	s.server.Close()
+}
+
+func (s *SubscriptionServer) publish(msg *message) {
+	s.subscriptions.publish(msg)
+}
+
+func (s *SubscriptionServer) serveSession(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Warnf("websocket upgrade failed, %s", err)
+		return
+	}
+	sub := newSubscriber()
+	s.subscriptions.add(sub)
+	go writeSession(conn, sub)
+
+	conn.SetReadLimit(maxRequestSize)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		resp, err := json.Marshal(handleRequest(sub, data))
+		if err != nil || !sub.push(resp) {
+			break
+		}
+	}
+	s.subscriptions.remove(sub)
+}
+
+// writeSession writes the queued messages until the subscriber is removed or
+// the session fails.
+func writeSession(conn *gws.Conn, sub *subscriber) {
+	defer conn.Close()
+	for data := range sub.send {
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := conn.WriteMessage(gws.TextMessage, data); err != nil {
+			return
+		}
+	}
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	conn.WriteMessage(gws.CloseMessage, gws.FormatCloseMessage(gws.CloseNormalClosure, ""))
+}
+
+func handleRequest(sub *subscriber, data []byte) *SubscriptionResponse {
+	var req SubscriptionRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return &SubscriptionResponse{Error: int(sideser.InvalidParams), Desc: "invalid request"}
+	}
+	resp := &SubscriptionResponse{Action: req.Action, Desc: "Success"}
+	switch req.Action {
+	case "subscribe":
+		id, err := sub.subscribe(&req.SubscribeRequest)
+		if err != nil {
+			resp.Error, resp.Desc = int(sideser.InvalidParams), err.Error()
+			break
+		}
+		resp.Result = id
+	case "unsubscribe":
+		if err := sub.unsubscribe(req.Id); err != nil {
+			resp.Error, resp.Desc = int(sideser.InvalidParams), err.Error()
+		}
+	default:
+		resp.Error, resp.Desc = int(sideser.InvalidParams), "unknown action "+req.Action
+	}
+	return resp
+}
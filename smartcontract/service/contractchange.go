@@ -0,0 +1,18 @@
+package service
+
+import (
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+// ContractChange records a contract created, migrated or destroyed by a
+// syscall, so the contract index can be updated once the execution is persisted.
+type ContractChange struct {
+	Action      states.ContractStatus
+	CodeHash    common.Uint168
+	NewCodeHash common.Uint168
+	Name        string
+	Author      string
+	Standards   []string
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"math"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	st "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
+)
+
+// RuntimeForkHeight is the first block whose transactions read their block
+// from the ExecutionEnvironment, the blocks below it read the best chain as
+// they did when they were persisted.
+var RuntimeForkHeight uint32 = math.MaxUint32
+
+var (
+	errBlockNotPersisted = errors.New("block is not persisted before the executing block")
+	errTxNotPersisted    = errors.New("transaction is not persisted before the executing transaction")
+)
+
+// ExecutionEnvironment is the block a transaction is executed in while the
+// block is persisted. From RuntimeForkHeight on the Runtime and Blockchain
+// syscalls read the block from it and do not see the blocks after it, nor the
+// transactions of the block from the executing one on, so a block gives the
+// same results whenever it is executed. Without an environment, which is the
+// case for RPC invocations, the syscalls read the best chain.
+type ExecutionEnvironment struct {
+	Header    *st.Header
+	Height    uint32
+	Timestamp uint32
+	Container *st.Transaction
+
+	block     *st.Block
+	blockHash common.Uint256
+}
+
+func NewExecutionEnvironment(block *st.Block, container *st.Transaction) *ExecutionEnvironment {
+	return &ExecutionEnvironment{
+		Header:    &block.Header,
+		Height:    block.Height,
+		Timestamp: block.Timestamp,
+		Container: container,
+		block:     block,
+		blockHash: block.Hash(),
+	}
+}
+
+// ExecutingHeight returns the height of the block the transaction is executed
+// in, an RPC invocation is executed as if in the next block.
+func (s *StateReader) ExecutingHeight() uint32 {
+	if s.Environment != nil {
+		return s.Environment.Height
+	}
+	if blockchain.DefaultChain != nil {
+		return blockchain.DefaultChain.BestChain.Height + 1
+	}
+	return 0
+}
+
+// persistingEnvironment returns the environment the Runtime and Blockchain
+// syscalls read, nil below RuntimeForkHeight.
+func (s *StateReader) persistingEnvironment() *ExecutionEnvironment {
+	if s.Environment == nil || s.Environment.Height < RuntimeForkHeight {
+		return nil
+	}
+	return s.Environment
+}
+
+// runtimeHeight returns the height the asset expirations are counted from,
+// the best chain is read below RuntimeForkHeight.
+func (s *StateReader) runtimeHeight() uint32 {
+	if env := s.persistingEnvironment(); env != nil {
+		return env.Height
+	}
+	if blockchain.DefaultChain != nil {
+		return blockchain.DefaultChain.BestChain.Height + 1
+	}
+	return 0
+}
+
+func (s *StateReader) getBlockHash(height uint32) (common.Uint256, error) {
+	if env := s.persistingEnvironment(); env != nil {
+		if height == env.Height {
+			return env.blockHash, nil
+		}
+		if height > env.Height {
+			return common.Uint256{}, errBlockNotPersisted
+		}
+	}
+	if blockchain.DefaultChain == nil {
+		return common.Uint256{}, errors.New("blockchain is not initialized")
+	}
+	return blockchain.DefaultChain.GetBlockHash(height)
+}
+
+func (s *StateReader) getHeader(hash common.Uint256) (*st.Header, error) {
+	env := s.persistingEnvironment()
+	if env != nil && hash.IsEqual(env.blockHash) {
+		return env.Header, nil
+	}
+	if blockchain.DefaultChain == nil {
+		return nil, errors.New("blockchain is not initialized")
+	}
+	header, err := blockchain.DefaultChain.GetHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	if env != nil && header.Height > env.Height {
+		return nil, errBlockNotPersisted
+	}
+	return header, nil
+}
+
+func (s *StateReader) getBlock(hash common.Uint256) (*st.Block, error) {
+	env := s.persistingEnvironment()
+	if env != nil && hash.IsEqual(env.blockHash) {
+		return env.block, nil
+	}
+	if blockchain.DefaultChain == nil {
+		return nil, errors.New("blockchain is not initialized")
+	}
+	block, err := blockchain.DefaultChain.GetBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if env != nil && block.Height > env.Height {
+		return nil, errBlockNotPersisted
+	}
+	return block, nil
+}
+
+func (s *StateReader) getTransaction(hash common.Uint256) (*st.Transaction, uint32, error) {
+	env := s.persistingEnvironment()
+	if env != nil {
+		// the executing transaction and the ones after it in the block are
+		// not persisted yet, only the ones before it are visible.
+		var containerHash common.Uint256
+		if env.Container != nil {
+			containerHash = env.Container.Hash()
+		}
+		persisted := true
+		for _, tx := range env.block.Transactions {
+			txHash := tx.Hash()
+			if env.Container != nil && txHash.IsEqual(containerHash) {
+				persisted = false
+			}
+			if hash.IsEqual(txHash) {
+				if !persisted {
+					return nil, 0, errTxNotPersisted
+				}
+				return tx, env.Height, nil
+			}
+		}
+	}
+	if blockchain.DefaultChain == nil {
+		return nil, 0, errors.New("blockchain is not initialized")
+	}
+	tx, height, err := blockchain.DefaultChain.GetTransaction(hash)
+	if err != nil {
+		return nil, 0, err
+	}
+	if env != nil && height > env.Height {
+		return nil, 0, errBlockNotPersisted
+	}
+	return tx, height, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	st "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
+	nt "github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+func setRuntimeForkHeight(height uint32) func() {
+	forkHeight := RuntimeForkHeight
+	RuntimeForkHeight = height
+	return func() { RuntimeForkHeight = forkHeight }
+}
+
+func TestEnvironmentGetTransaction(t *testing.T) {
+	defer setRuntimeForkHeight(0)()
+	block := &st.Block{Header: st.Header{Height: 10}}
+	for i := byte(0); i < 3; i++ {
+		block.Transactions = append(block.Transactions, &st.Transaction{
+			TxType:  st.Invoke,
+			Payload: &nt.PayloadInvoke{Code: []byte{i}},
+		})
+	}
+	s := NewStateReader()
+	s.Environment = NewExecutionEnvironment(block, block.Transactions[1])
+
+	tx, height, err := s.getTransaction(block.Transactions[0].Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, block.Transactions[0], tx)
+	assert.Equal(t, uint32(10), height)
+	for _, tx := range block.Transactions[1:] {
+		_, _, err = s.getTransaction(tx.Hash())
+		assert.Equal(t, errTxNotPersisted, err)
+	}
+}
+
+func TestEnvironmentForkHeight(t *testing.T) {
+	defer func(chain *blockchain.AVMChain) { blockchain.DefaultChain = chain }(blockchain.DefaultChain)
+	blockchain.DefaultChain = &blockchain.AVMChain{BlockChain: &sb.BlockChain{
+		BestChain: &sb.BlockNode{Height: 12, Timestamp: 1500000012},
+	}}
+	s := NewStateReader()
+	s.Environment = NewExecutionEnvironment(&st.Block{Header: st.Header{Height: 10, Timestamp: 1500000010}}, nil)
+	engine := avm.NewExecutionEngine(nil, nil, 1024, nil, nil, 0, avm.Application, false)
+	runtime := func() (int, int, uint32) {
+		assert.True(t, s.RuntimeGetTime(engine))
+		assert.True(t, s.BlockChainGetHeight(engine))
+		height := avm.PopInt(engine)
+		return avm.PopInt(engine), height, s.runtimeHeight()
+	}
+
+	// below the fork height the best chain is read as it was
+	defer setRuntimeForkHeight(11)()
+	timestamp, height, runtimeHeight := runtime()
+	assert.Equal(t, 1500000012, timestamp)
+	assert.Equal(t, 12, height)
+	assert.Equal(t, uint32(13), runtimeHeight)
+
+	RuntimeForkHeight = 10
+	timestamp, height, runtimeHeight = runtime()
+	assert.Equal(t, 1500000010, timestamp)
+	assert.Equal(t, 10, height)
+	assert.Equal(t, uint32(10), runtimeHeight)
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+)
+
+const (
+	// serializeMagic starts a versioned serialization, it is not a stack item
+	// type so the data written before the versioned format is still read.
+	serializeMagic byte = 0xff
+
+	// SerializeVersion is the version of the canonical format written by
+	// Runtime.Serialize. The item that follows the version is encoded as
+	// NEO does, integers are two's complement little endian and map entries
+	// are sorted by their serialized keys.
+	SerializeVersion byte = 0x01
+
+	// MaxSerializeDepth is the max nesting of arrays, structs and maps.
+	MaxSerializeDepth = 16
+)
+
+var (
+	ErrSerializeInterop  = errors.New("interop item can not be serialized")
+	ErrSerializeDepth    = errors.New("stack item exceeds the max serialize depth")
+	ErrSerializeSize     = errors.New("stack item exceeds the max serialize size")
+	ErrSerializeMapKey   = errors.New("map key must be a boolean, integer or byte array")
+	ErrSerializeVersion  = errors.New("unknown serialize version")
+	ErrSerializeType     = errors.New("unknown stack item type")
+	ErrSerializeEncoding = errors.New("stack item is not canonically encoded")
+)
+
+// SerializeStackItem writes the item in the canonical format, prefixed by the
+// format version.
+func (s *StateReader) SerializeStackItem(item datatype.StackItem, w io.Writer) error {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{serializeMagic, SerializeVersion})
+	if err := serializeStackItem(item, buf, 0); err != nil {
+		return err
+	}
+	if buf.Len() > int(avm.MaxItemSize) {
+		return ErrSerializeSize
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// DerializeStackItem reads an item written by SerializeStackItem, or by the
+// unversioned format used before it.
+func (s *StateReader) DerializeStackItem(r io.Reader) (datatype.StackItem, error) {
+	lr := &limitedReader{r: r, n: int64(avm.MaxItemSize)}
+	itemType, err := common.ReadUint8(lr)
+	if err != nil {
+		return nil, err
+	}
+	if itemType != serializeMagic {
+		return deserializeLegacyStackItem(lr, datatype.StackItemType(itemType), 0, true)
+	}
+	version, err := common.ReadUint8(lr)
+	if err != nil {
+		return nil, err
+	}
+	if version != SerializeVersion {
+		return nil, ErrSerializeVersion
+	}
+	return deserializeStackItem(lr, 0)
+}
+
+func serializeStackItem(item datatype.StackItem, w *bytes.Buffer, depth int) error {
+	if w.Len() > int(avm.MaxItemSize) {
+		return ErrSerializeSize
+	}
+	switch v := item.(type) {
+	case *datatype.Boolean:
+		w.WriteByte(byte(datatype.TYPE_Boolean))
+		if v.GetBoolean() {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	case *datatype.Integer:
+		w.WriteByte(byte(datatype.TYPE_Integer))
+		common.WriteVarBytes(w, BigIntToBytes(v.GetBigInteger()))
+	case *datatype.ByteArray:
+		w.WriteByte(byte(datatype.TYPE_ByteArray))
+		common.WriteVarBytes(w, v.GetByteArray())
+	case *datatype.Array:
+		if depth >= MaxSerializeDepth {
+			return ErrSerializeDepth
+		}
+		if v.IsStruct() {
+			w.WriteByte(byte(datatype.TYPE_Struct))
+		} else {
+			w.WriteByte(byte(datatype.TYPE_Array))
+		}
+		items := v.GetArray()
+		common.WriteVarUint(w, uint64(len(items)))
+		for _, item := range items {
+			if err := serializeStackItem(item, w, depth+1); err != nil {
+				return err
+			}
+		}
+	case *datatype.Dictionary:
+		if depth >= MaxSerializeDepth {
+			return ErrSerializeDepth
+		}
+		type entry struct {
+			key   []byte
+			value datatype.StackItem
+		}
+		entries := make([]entry, 0, len(v.GetMap()))
+		for key, value := range v.GetMap() {
+			buf := new(bytes.Buffer)
+			if err := serializeMapKey(key, buf); err != nil {
+				return err
+			}
+			entries = append(entries, entry{key: buf.Bytes(), value: value})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i].key, entries[j].key) < 0
+		})
+		w.WriteByte(byte(datatype.TYPE_Map))
+		common.WriteVarUint(w, uint64(len(entries)))
+		for _, e := range entries {
+			w.Write(e.key)
+			if err := serializeStackItem(e.value, w, depth+1); err != nil {
+				return err
+			}
+		}
+	case *datatype.GeneralInterface:
+		return ErrSerializeInterop
+	default:
+		return ErrSerializeType
+	}
+	return nil
+}
+
+func serializeMapKey(key datatype.StackItem, w *bytes.Buffer) error {
+	switch key.(type) {
+	case *datatype.Boolean, *datatype.Integer, *datatype.ByteArray:
+		return serializeStackItem(key, w, 0)
+	}
+	return ErrSerializeMapKey
+}
+
+func deserializeStackItem(r io.Reader, depth int) (datatype.StackItem, error) {
+	itemType, err := common.ReadUint8(r)
+	if err != nil {
+		return nil, err
+	}
+	switch datatype.StackItemType(itemType) {
+	case datatype.TYPE_ByteArray:
+		data, err := common.ReadVarBytes(r, avm.MaxItemSize, "StackItem ByteArray")
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewByteArray(data), nil
+	case datatype.TYPE_Boolean:
+		data, err := common.ReadUint8(r)
+		if err != nil {
+			return nil, err
+		}
+		if data > 1 {
+			return nil, ErrSerializeEncoding
+		}
+		return datatype.NewBoolean(data == 1), nil
+	case datatype.TYPE_Integer:
+		data, err := common.ReadVarBytes(r, avm.MAX_BIGINTEGER, "StackItem Integer")
+		if err != nil {
+			return nil, err
+		}
+		value := BytesToBigInt(data)
+		if !bytes.Equal(BigIntToBytes(value), data) {
+			return nil, ErrSerializeEncoding
+		}
+		return datatype.NewInteger(value), nil
+	case datatype.TYPE_Array, datatype.TYPE_Struct:
+		if depth >= MaxSerializeDepth {
+			return nil, ErrSerializeDepth
+		}
+		count, err := common.ReadVarUint(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count > uint64(avm.MaxArraySize) {
+			return nil, ErrSerializeSize
+		}
+		items := make([]datatype.StackItem, count)
+		for i := range items {
+			if items[i], err = deserializeStackItem(r, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		if datatype.StackItemType(itemType) == datatype.TYPE_Struct {
+			return datatype.NewStruct(items), nil
+		}
+		return datatype.NewArray(items), nil
+	case datatype.TYPE_Map:
+		if depth >= MaxSerializeDepth {
+			return nil, ErrSerializeDepth
+		}
+		count, err := common.ReadVarUint(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count > uint64(avm.MaxArraySize) {
+			return nil, ErrSerializeSize
+		}
+		dictionary := datatype.NewDictionary()
+		var lastKey []byte
+		for i := uint64(0); i < count; i++ {
+			key, err := deserializeStackItem(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			buf := new(bytes.Buffer)
+			if err := serializeMapKey(key, buf); err != nil {
+				return nil, err
+			}
+			// Keys are sorted and unique in the canonical format.
+			if i > 0 && bytes.Compare(lastKey, buf.Bytes()) >= 0 {
+				return nil, ErrSerializeEncoding
+			}
+			lastKey = buf.Bytes()
+			value, err := deserializeStackItem(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			dictionary.PutStackItem(key, value)
+		}
+		return dictionary, nil
+	case datatype.TYPE_InteropInterface:
+		return nil, ErrSerializeInterop
+	}
+	return nil, ErrSerializeType
+}
+
+// serializeLegacyStackItem writes the unversioned format Runtime.Serialize
+// wrote before VMForkHeight. Integers lose their sign, an interop item is
+// written as its type alone and a struct as an array. Map entries were
+// written in map order, they are written sorted so the output is one the
+// nodes before the fork may have written.
+func serializeLegacyStackItem(item datatype.StackItem, w *bytes.Buffer) {
+	switch item.(type) {
+	case *datatype.Boolean:
+		w.WriteByte(byte(datatype.TYPE_Boolean))
+		w.Write(item.GetByteArray())
+	case *datatype.Integer:
+		w.WriteByte(byte(datatype.TYPE_Integer))
+		common.WriteVarBytes(w, item.GetBigInteger().Bytes())
+	case *datatype.ByteArray:
+		w.WriteByte(byte(datatype.TYPE_ByteArray))
+		common.WriteVarBytes(w, item.GetByteArray())
+	case *datatype.GeneralInterface:
+		w.WriteByte(byte(datatype.TYPE_InteropInterface))
+		w.Write(item.GetByteArray())
+	case *datatype.Array:
+		w.WriteByte(byte(datatype.TYPE_Array))
+		items := item.GetArray()
+		common.WriteVarUint(w, uint64(len(items)))
+		for _, item := range items {
+			serializeLegacyStackItem(item, w)
+		}
+	case *datatype.Dictionary:
+		entries := make([][]byte, 0, len(item.GetMap()))
+		for key, value := range item.GetMap() {
+			entry := new(bytes.Buffer)
+			serializeLegacyStackItem(key, entry)
+			serializeLegacyStackItem(value, entry)
+			entries = append(entries, entry.Bytes())
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i], entries[j]) < 0
+		})
+		w.WriteByte(byte(datatype.TYPE_Map))
+		common.WriteVarUint(w, uint64(len(entries)))
+		for _, entry := range entries {
+			w.Write(entry)
+		}
+	}
+}
+
+// deserializeLegacyStackItem reads the unversioned format, in which integers
+// are unsigned big endian and map entries are not sorted. Before the fork the
+// depth is not limited and the maps keep the duplicate keys of the data.
+func deserializeLegacyStackItem(r io.Reader, itemType datatype.StackItemType, depth int,
+	forked bool) (datatype.StackItem, error) {
+	switch itemType {
+	case datatype.TYPE_ByteArray:
+		data, err := common.ReadVarBytes(r, avm.MaxItemSize, "StackItem ByteArray")
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewByteArray(data), nil
+	case datatype.TYPE_Boolean:
+		data, err := common.ReadUint8(r)
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewBoolean(data != 0), nil
+	case datatype.TYPE_Integer:
+		data, err := common.ReadVarBytes(r, avm.MAX_BIGINTEGER, "StackItem Integer")
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewInteger(new(big.Int).SetBytes(data)), nil
+	case datatype.TYPE_Array, datatype.TYPE_Struct, datatype.TYPE_Map:
+		// Before the fork only the data bounds the items, every item takes a
+		// byte at least.
+		maxCount := uint64(avm.MaxItemSize)
+		if forked {
+			if depth >= MaxSerializeDepth {
+				return nil, ErrSerializeDepth
+			}
+			maxCount = uint64(avm.MaxArraySize)
+		}
+		count, err := common.ReadVarUint(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count > maxCount {
+			return nil, ErrSerializeSize
+		}
+		if itemType == datatype.TYPE_Map {
+			dictionary := datatype.NewDictionary()
+			for i := uint64(0); i < count; i++ {
+				key, err := readLegacyStackItem(r, depth+1, forked)
+				if err != nil {
+					return nil, err
+				}
+				value, err := readLegacyStackItem(r, depth+1, forked)
+				if err != nil {
+					return nil, err
+				}
+				if forked {
+					dictionary.PutStackItem(key, value)
+				} else {
+					dictionary.GetMap()[key] = value
+				}
+			}
+			return dictionary, nil
+		}
+		items := make([]datatype.StackItem, count)
+		for i := range items {
+			if items[i], err = readLegacyStackItem(r, depth+1, forked); err != nil {
+				return nil, err
+			}
+		}
+		return datatype.NewArray(items), nil
+	case datatype.TYPE_InteropInterface:
+		return nil, ErrSerializeInterop
+	}
+	return nil, ErrSerializeType
+}
+
+func readLegacyStackItem(r io.Reader, depth int, forked bool) (datatype.StackItem, error) {
+	itemType, err := common.ReadUint8(r)
+	if err != nil {
+		return nil, err
+	}
+	return deserializeLegacyStackItem(r, datatype.StackItemType(itemType), depth, forked)
+}
+
+// BigIntToBytes returns the minimal two's complement little endian encoding
+// of the value, zero is encoded as no bytes.
+func BigIntToBytes(value *big.Int) []byte {
+	switch value.Sign() {
+	case 0:
+		return []byte{}
+	case 1:
+		data := value.Bytes()
+		if data[0]&0x80 != 0 {
+			data = append([]byte{0}, data...)
+		}
+		return common.BytesReverse(data)
+	}
+	// The bytes of -value-1 inverted are the two's complement of value.
+	data := new(big.Int).Sub(new(big.Int).Neg(value), big.NewInt(1)).Bytes()
+	for i := range data {
+		data[i] = ^data[i]
+	}
+	if len(data) == 0 || data[0]&0x80 == 0 {
+		data = append([]byte{0xff}, data...)
+	}
+	return common.BytesReverse(data)
+}
+
+// BytesToBigInt decodes a two's complement little endian value.
+func BytesToBigInt(data []byte) *big.Int {
+	if len(data) == 0 {
+		return big.NewInt(0)
+	}
+	be := common.BytesReverse(append([]byte{}, data...))
+	if be[0]&0x80 == 0 {
+		return new(big.Int).SetBytes(be)
+	}
+	for i := range be {
+		be[i] = ^be[i]
+	}
+	value := new(big.Int).SetBytes(be)
+	return value.Neg(value.Add(value, big.NewInt(1)))
+}
+
+// limitedReader fails once more than n bytes are read, it bounds the total
+// size of a deserialized item.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.n {
+		return 0, ErrSerializeSize
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
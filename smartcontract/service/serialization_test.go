@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+)
+
+func TestBigIntToBytes(t *testing.T) {
+	// Vectors of System.Numerics.BigInteger.ToByteArray used by NEO, except
+	// zero which NEO serializes as no bytes.
+	vectors := map[int64]string{
+		0:      "",
+		1:      "01",
+		-1:     "ff",
+		-5:     "fb",
+		127:    "7f",
+		128:    "8000",
+		255:    "ff00",
+		-128:   "80",
+		-129:   "7fff",
+		-256:   "00ff",
+		65535:  "ffff00",
+		-65536: "0000ff",
+	}
+	for value, expected := range vectors {
+		data := BigIntToBytes(big.NewInt(value))
+		assert.Equal(t, expected, hex.EncodeToString(data), "encode %d", value)
+		assert.Equal(t, value, BytesToBigInt(data).Int64(), "decode %d", value)
+	}
+}
+
+func TestSerializeStackItem(t *testing.T) {
+	dict := datatype.NewDictionary()
+	dict.PutStackItem(datatype.NewByteArray([]byte("b")), datatype.NewInteger(big.NewInt(1)))
+	dict.PutStackItem(datatype.NewByteArray([]byte("a")), datatype.NewInteger(big.NewInt(-2)))
+
+	// The vectors after the version are the bytes NEO serializes the same
+	// items to, with map entries in key order.
+	vectors := []struct {
+		item     datatype.StackItem
+		expected string
+	}{
+		{datatype.NewInteger(big.NewInt(-5)), "0201fb"},
+		{datatype.NewInteger(big.NewInt(0)), "0200"},
+		{datatype.NewInteger(big.NewInt(128)), "02028000"},
+		{datatype.NewBoolean(true), "0101"},
+		{datatype.NewByteArray([]byte("abc")), "0003616263"},
+		{datatype.NewArray([]datatype.StackItem{
+			datatype.NewInteger(big.NewInt(1)),
+			datatype.NewBoolean(false),
+		}), "8002020101" + "0100"},
+		{datatype.NewStruct([]datatype.StackItem{
+			datatype.NewByteArray([]byte{}),
+		}), "810100" + "00"},
+		{dict, "8202" + "000161" + "0201fe" + "000162" + "020101"},
+	}
+	s := new(StateReader)
+	for _, v := range vectors {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, s.SerializeStackItem(v.item, buf))
+		assert.Equal(t, "ff01"+v.expected, hex.EncodeToString(buf.Bytes()))
+
+		item, err := s.DerializeStackItem(bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+		if array, ok := v.item.(*datatype.Array); ok {
+			assert.Equal(t, array.IsStruct(), item.(*datatype.Array).IsStruct())
+		}
+
+		again := new(bytes.Buffer)
+		assert.NoError(t, s.SerializeStackItem(item, again))
+		assert.Equal(t, buf.Bytes(), again.Bytes())
+	}
+}
+
+func TestSerializeStackItemLimits(t *testing.T) {
+	s := new(StateReader)
+
+	err := s.SerializeStackItem(datatype.NewGeneralInterface(nil), new(bytes.Buffer))
+	assert.Equal(t, ErrSerializeInterop, err)
+
+	var item datatype.StackItem = datatype.NewBoolean(true)
+	for i := 0; i < MaxSerializeDepth; i++ {
+		item = datatype.NewArray([]datatype.StackItem{item})
+	}
+	assert.NoError(t, s.SerializeStackItem(item, new(bytes.Buffer)))
+	item = datatype.NewArray([]datatype.StackItem{item})
+	assert.Equal(t, ErrSerializeDepth, s.SerializeStackItem(item, new(bytes.Buffer)))
+
+	// An array that contains itself is stopped by the depth limit.
+	cycle := datatype.NewArray(make([]datatype.StackItem, 1))
+	cycle.GetArray()[0] = cycle
+	assert.Equal(t, ErrSerializeDepth, s.SerializeStackItem(cycle, new(bytes.Buffer)))
+
+	invalid := []string{
+		"ff02" + "0101",     // unknown version
+		"ff01" + "0102",     // boolean other than 0 and 1
+		"ff01" + "020100",   // zero with a byte
+		"ff01" + "02027f00", // 127 encoded with an extra byte
+		"ff01" + "40",       // interop item
+		"ff01" + "8202" + "000162" + "0101" + "000161" + "0101", // unsorted map
+		"ff01" + "8201" + "8000" + "0101",                       // array as a map key
+	}
+	for _, data := range invalid {
+		raw, _ := hex.DecodeString(data)
+		_, err := s.DerializeStackItem(bytes.NewReader(raw))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestDerializeLegacyStackItem(t *testing.T) {
+	// Items serialized before the versioned format have no version prefix
+	// and unsigned big endian integers.
+	raw, _ := hex.DecodeString("8002" + "02020100" + "0003616263")
+	item, err := new(StateReader).DerializeStackItem(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	items := item.GetArray()
+	assert.Equal(t, int64(256), items[0].GetBigInteger().Int64())
+	assert.Equal(t, []byte("abc"), items[1].GetByteArray())
+}
+
+func TestSerializeLegacyStackItem(t *testing.T) {
+	// Before the fork integers lose their sign and structs are arrays.
+	item := datatype.NewStruct([]datatype.StackItem{
+		datatype.NewInteger(big.NewInt(-5)),
+		datatype.NewByteArray([]byte("abc")),
+	})
+	buf := new(bytes.Buffer)
+	serializeLegacyStackItem(item, buf)
+	assert.Equal(t, "8002"+"020105"+"0003616263", hex.EncodeToString(buf.Bytes()))
+
+	// Maps keep duplicate keys before the fork and drop them after it.
+	raw, _ := hex.DecodeString("8202" + "000161" + "0101" + "000161" + "0100")
+	legacy, err := readLegacyStackItem(bytes.NewReader(raw), 0, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(legacy.GetMap()))
+	forked, err := readLegacyStackItem(bytes.NewReader(raw), 0, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(forked.GetMap()))
+}
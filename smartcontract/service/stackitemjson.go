@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+)
+
+// StackItemToJson converts the item to the {"type", "value"} form NEO uses
+// for stack items in JSON. Map entries are ordered by their serialized keys
+// and interop items have no value.
+func StackItemToJson(item datatype.StackItem) interface{} {
+	return stackItemToJson(item, 0)
+}
+
+func stackItemToJson(item datatype.StackItem, depth int) interface{} {
+	if depth > MaxSerializeDepth {
+		return map[string]interface{}{"type": "Unknown"}
+	}
+	switch v := item.(type) {
+	case *datatype.Boolean:
+		return map[string]interface{}{"type": "Boolean", "value": v.GetBoolean()}
+	case *datatype.Integer:
+		return map[string]interface{}{"type": "Integer", "value": v.GetBigInteger().String()}
+	case *datatype.ByteArray:
+		return map[string]interface{}{"type": "ByteArray", "value": common.BytesToHexString(v.GetByteArray())}
+	case *datatype.Array:
+		items := v.GetArray()
+		values := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			values = append(values, stackItemToJson(item, depth+1))
+		}
+		itemType := "Array"
+		if v.IsStruct() {
+			itemType = "Struct"
+		}
+		return map[string]interface{}{"type": itemType, "value": values}
+	case *datatype.Dictionary:
+		type entry struct {
+			key   []byte
+			value interface{}
+		}
+		entries := make([]entry, 0, len(v.GetMap()))
+		for key, value := range v.GetMap() {
+			buf := new(bytes.Buffer)
+			serializeMapKey(key, buf)
+			entries = append(entries, entry{
+				key: buf.Bytes(),
+				value: map[string]interface{}{
+					"key":   stackItemToJson(key, depth+1),
+					"value": stackItemToJson(value, depth+1),
+				},
+			})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i].key, entries[j].key) < 0
+		})
+		values := make([]interface{}, 0, len(entries))
+		for _, e := range entries {
+			values = append(values, e.value)
+		}
+		return map[string]interface{}{"type": "Map", "value": values}
+	}
+	return map[string]interface{}{"type": "InteropInterface"}
+}
+
+// StackItemFromJson converts a stack item decoded from JSON by StackItemToJson
+// back to the item, interop items can not be converted.
+func StackItemFromJson(data interface{}) (datatype.StackItem, error) {
+	return stackItemFromJson(data, 0)
+}
+
+func stackItemFromJson(data interface{}, depth int) (datatype.StackItem, error) {
+	if depth > MaxSerializeDepth {
+		return nil, ErrSerializeDepth
+	}
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("stack item must be an object")
+	}
+	itemType, _ := object["type"].(string)
+	value := object["value"]
+	switch itemType {
+	case "Boolean":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, errors.New("invalid Boolean value")
+		}
+		return datatype.NewBoolean(b), nil
+	case "Integer":
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("invalid Integer value")
+		}
+		i, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, errors.New("invalid Integer value")
+		}
+		return datatype.NewInteger(i), nil
+	case "ByteArray":
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("invalid ByteArray value")
+		}
+		b, err := common.HexStringToBytes(s)
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewByteArray(b), nil
+	case "Array", "Struct":
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.New("invalid " + itemType + " value")
+		}
+		items := make([]datatype.StackItem, 0, len(values))
+		for _, v := range values {
+			item, err := stackItemFromJson(v, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if itemType == "Struct" {
+			return datatype.NewStruct(items), nil
+		}
+		return datatype.NewArray(items), nil
+	case "Map":
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.New("invalid Map value")
+		}
+		dictionary := datatype.NewDictionary()
+		for _, v := range values {
+			entry, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("invalid Map entry")
+			}
+			key, err := stackItemFromJson(entry["key"], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if err := serializeMapKey(key, new(bytes.Buffer)); err != nil {
+				return nil, err
+			}
+			item, err := stackItemFromJson(entry["value"], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			dictionary.PutStackItem(key, item)
+		}
+		return dictionary, nil
+	}
+	return nil, errors.New("unsupported stack item type " + itemType)
+}
+
+// NotifyEventName returns the event name of a notification, which by
+// convention is the first element of the notified array. It is empty if the
+// first element is not a printable string of at most 32 bytes.
+func NotifyEventName(item datatype.StackItem) string {
+	array, ok := item.(*datatype.Array)
+	if !ok || len(array.GetArray()) == 0 {
+		return ""
+	}
+	name, ok := array.GetArray()[0].(*datatype.ByteArray)
+	if !ok {
+		return ""
+	}
+	data := name.GetByteArray()
+	if len(data) == 0 || len(data) > 32 || !utf8.Valid(data) {
+		return ""
+	}
+	for _, r := range string(data) {
+		if r < 0x20 || r == 0x7f {
+			return ""
+		}
+	}
+	return string(data)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+)
+
+func TestStackItemJson(t *testing.T) {
+	dict := datatype.NewDictionary()
+	dict.PutStackItem(datatype.NewByteArray([]byte("b")), datatype.NewBoolean(true))
+	dict.PutStackItem(datatype.NewInteger(big.NewInt(7)), datatype.NewStruct(nil))
+	item := datatype.NewArray([]datatype.StackItem{
+		datatype.NewByteArray([]byte("transfer")),
+		datatype.NewInteger(big.NewInt(-300)),
+		dict,
+	})
+
+	data, err := json.Marshal(StackItemToJson(item))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"Array","value":[`+
+		`{"type":"ByteArray","value":"7472616e73666572"},`+
+		`{"type":"Integer","value":"-300"},`+
+		`{"type":"Map","value":[`+
+		`{"key":{"type":"ByteArray","value":"62"},"value":{"type":"Boolean","value":true}},`+
+		`{"key":{"type":"Integer","value":"7"},"value":{"type":"Struct","value":[]}}]}]}`, string(data))
+
+	var value interface{}
+	assert.NoError(t, json.Unmarshal(data, &value))
+	decoded, err := StackItemFromJson(value)
+	assert.NoError(t, err)
+
+	s := new(StateReader)
+	expected, actual := new(bytes.Buffer), new(bytes.Buffer)
+	assert.NoError(t, s.SerializeStackItem(item, expected))
+	assert.NoError(t, s.SerializeStackItem(decoded, actual))
+	assert.Equal(t, expected.Bytes(), actual.Bytes())
+
+	_, err = StackItemFromJson(map[string]interface{}{"type": "InteropInterface"})
+	assert.Error(t, err)
+}
+
+func TestNotifyEventName(t *testing.T) {
+	name := func(first datatype.StackItem) string {
+		return NotifyEventName(datatype.NewArray([]datatype.StackItem{first}))
+	}
+	assert.Equal(t, "transfer", name(datatype.NewByteArray([]byte("transfer"))))
+	assert.Equal(t, "", name(datatype.NewByteArray([]byte{0x01, 0x02})))
+	assert.Equal(t, "", name(datatype.NewInteger(big.NewInt(1))))
+	assert.Equal(t, "", NotifyEventName(datatype.NewByteArray([]byte("transfer"))))
+	assert.Equal(t, "", NotifyEventName(datatype.NewArray(nil)))
+}
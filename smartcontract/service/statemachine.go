@@ -18,13 +18,13 @@ import (
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
 	nt "github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
-	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
 
 )
 
 type StateMachine struct {
 	*StateReader
-	CloneCache *storage.CloneCache
+	CloneCache      *storage.CloneCache
+	ContractChanges []*ContractChange
 }
 
 func NewStateMachine(dbCache storage.DBCache, innerCache storage.DBCache) *StateMachine {
@@ -102,7 +102,7 @@ func (s *StateMachine) CreateAsset(engine *avm.ExecutionEngine) bool {
 		Admin:      *admin,
 		Issuer:     *issue,
 		Owner:      owner,
-		Expiration: blockchain.DefaultChain.BestChain.Height + 1 + 2000000,
+		Expiration: s.runtimeHeight() + 2000000,
 		IsFrozen:   false,
 	}
 	s.CloneCache.GetInnerCache().GetWriteSet().Add(sb.ST_AssetState, string(assetID.Bytes()), assetState)
@@ -160,6 +160,12 @@ func (s *StateMachine) CreateContract(engine *avm.ExecutionEngine) bool {
 	codeHash := funcCode.CodeHash()
 	key := params.UInt168ToUInt160(&codeHash)
 	s.CloneCache.GetInnerCache().GetOrAdd(sb.ST_Contract, string(key), contractState)
+	s.ContractChanges = append(s.ContractChanges, &ContractChange{
+		Action:   states.ContractDeployed,
+		CodeHash: codeHash,
+		Name:     string(nameByte),
+		Author:   string(authorByte),
+	})
 	avm.PushData(engine, contractState)
 	return true
 }
@@ -259,14 +265,25 @@ func (s *StateMachine) ContractMigrate(engine *avm.ExecutionEngine) bool {
 		}
 	}
 	avm.PushData(engine, item.(*states.ContractState))
-	return s.ContractDestory(engine)
+	oldHash, ok := s.destroyContract(engine)
+	if !ok {
+		return false
+	}
+	s.ContractChanges = append(s.ContractChanges, &ContractChange{
+		Action:      states.ContractMigrated,
+		CodeHash:    *oldHash,
+		NewCodeHash: codeHash,
+		Name:        string(nameByte),
+		Author:      string(authorByte),
+	})
+	return true
 }
 
 func (s *StateMachine) AssetRenew(engine *avm.ExecutionEngine) bool {
 	data := avm.PopInteropInterface(engine)
 	years := avm.PopInt(engine)
 	at := data.(*states.AssetState)
-	height := blockchain.DefaultChain.BestChain.Height + 1
+	height := s.runtimeHeight()
 	b := new(bytes.Buffer)
 	at.AssetId.Serialize(b)
 	state, err := s.CloneCache.TryGet(sb.ST_AssetState, b.String())
@@ -288,25 +305,48 @@ func (s *StateMachine) AssetRenew(engine *avm.ExecutionEngine) bool {
 }
 
 func (s *StateMachine) ContractDestory(engine *avm.ExecutionEngine) bool {
+	hash, ok := s.destroyContract(engine)
+	if !ok {
+		return false
+	}
+	s.ContractChanges = append(s.ContractChanges, &ContractChange{
+		Action:   states.ContractDestroyed,
+		CodeHash: *hash,
+	})
+	return true
+}
+
+func (s *StateMachine) destroyContract(engine *avm.ExecutionEngine) (*common.Uint168, bool) {
 	data := engine.ExecutingScript()
 	if data == nil {
-		return false
+		return nil, false
 	}
 	hash, err := params.ToCodeHash(data)
 	if err != nil {
-		return false
+		return nil, false
 	}
 	keyStr := string(params.UInt168ToUInt160(hash))
 	item, err := s.CloneCache.TryGet(sb.ST_Contract, keyStr)
 	if err != nil || item == nil {
 		log.Error("ContractDestory:", err.Error())
-		return false
+		return nil, false
 	}
 	if !engine.IsTestMode() {
 		s.CloneCache.GetInnerCache().TryDelete(sb.ST_Contract, keyStr)
 	}
 
-	return true
+	return hash, true
+}
+
+// PushSnapshot gives a called contract its own snapshot of the state.
+func (s *StateMachine) PushSnapshot() {
+	s.CloneCache.PushSnapshot()
+}
+
+// PopSnapshot ends the snapshot of a called contract, merging its writes into
+// the caller's snapshot if commit is true.
+func (s *StateMachine) PopSnapshot(commit bool) {
+	s.CloneCache.PopSnapshot(commit)
 }
 
 func (s *StateMachine) CheckStorageContext(context *StorageContext) (bool, error) {
@@ -397,8 +437,7 @@ func (s *StateMachine) StorageFind(engine *avm.ExecutionEngine) bool {
 }
 
 func (s *StateMachine) GetStorageContext(engine *avm.ExecutionEngine) bool {
-	data := engine.CurrentContext().GetCodeHash()
-	codeHash, err := common.Uint168FromBytes(data)
+	codeHash, err := common.Uint168FromBytes(engine.Hash168(engine.ExecutingScript()))
 	if err != nil {
 		return false
 	}
@@ -3,7 +3,6 @@ package service
 import (
 	"math/big"
 	"errors"
-	"io"
 	"bytes"
 
 	"github.com/elastos/Elastos.ELA.Utility/common"
@@ -11,7 +10,6 @@ import (
 
 	st "github.com/elastos/Elastos.ELA.SideChain/types"
 	"github.com/elastos/Elastos.ELA.SideChain/database"
-	"github.com/elastos/Elastos.ELA.SideChain/events"
 
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
@@ -20,12 +18,33 @@ import (
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
-	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
 )
 
 type StateReader struct {
-	serviceMap map[string]func(engine *avm.ExecutionEngine) bool
+	serviceMap    map[string]func(engine *avm.ExecutionEngine) bool
+	Notifications []*states.NotifyEvent
+	Logs          []*states.LogEvent
+	NotifyEvents  []*NotifyEventArgs
+	// LogItems are the items of the Neo.Runtime.Log calls, they are published
+	// as ETRunTimeLog events once the transaction is persisted.
+	LogItems []datatype.StackItem
+
+	// Environment is the block being persisted, it is nil for RPC invocations.
+	Environment *ExecutionEnvironment
+}
+
+// NotifyEventArgs is a Neo.Runtime.Notify attributed to the contract and the
+// transaction that raised it. The notifications of a transaction are published
+// as ETRunTimeNotify events once the transaction is persisted.
+type NotifyEventArgs struct {
+	ScriptHash common.Uint168
+	TxID       common.Uint256
+	Height     uint32
+	// Index is the position of the notification within the transaction.
+	Index     int
+	EventName string
+	Item      datatype.StackItem
 }
 
 func NewStateReader() *StateReader {
@@ -131,17 +150,55 @@ func (s *StateReader) RuntimeGetTrigger(e *avm.ExecutionEngine) bool {
 
 func (s *StateReader) RuntimeNotify(e *avm.ExecutionEngine) bool {
 	item := avm.PopStackItem(e)
-	events.Notify(event.ETRunTimeNotify, item)
+	// The state of an item that can not be serialized, such as an interop
+	// item, is left empty, the published event still carries the item.
+	var state []byte
+	buf := new(bytes.Buffer)
+	if err := s.SerializeStackItem(item, buf); err == nil {
+		state = buf.Bytes()
+	}
+	args := &NotifyEventArgs{
+		ScriptHash: executingCodeHash(e),
+		Height:     s.ExecutingHeight(),
+		Index:      len(s.Notifications),
+		EventName:  NotifyEventName(item),
+		Item:       item,
+	}
+	if tx, ok := e.GetDataContainer().(*st.Transaction); ok {
+		args.TxID = tx.Hash()
+	}
+	s.Notifications = append(s.Notifications, &states.NotifyEvent{
+		CodeHash: args.ScriptHash,
+		State:    state,
+	})
+	s.NotifyEvents = append(s.NotifyEvents, args)
 	return true
 }
 
 func (s *StateReader) RuntimeLog(e *avm.ExecutionEngine) bool {
 	data := avm.PopStackItem(e)
-	events.Notify(event.ETRunTimeLog, data)
+	s.Logs = append(s.Logs, &states.LogEvent{
+		CodeHash: executingCodeHash(e),
+		Message:  string(data.GetByteArray()),
+	})
+	s.LogItems = append(s.LogItems, data)
 	return true
 }
 
+func executingCodeHash(e *avm.ExecutionEngine) common.Uint168 {
+	var codeHash common.Uint168
+	hash, err := common.Uint168FromBytes(e.Hash168(e.ExecutingScript()))
+	if err == nil {
+		codeHash = *hash
+	}
+	return codeHash
+}
+
 func (s *StateReader) RuntimeGetTime(e *avm.ExecutionEngine) bool {
+	if env := s.persistingEnvironment(); env != nil {
+		avm.PushData(e, env.Timestamp)
+		return true
+	}
 	if blockchain.DefaultChain == nil {
 		return false
 	}
@@ -150,10 +207,16 @@ func (s *StateReader) RuntimeGetTime(e *avm.ExecutionEngine) bool {
 	return true
 }
 
+// RuntimeSerialize writes the canonical format from VMForkHeight on, and the
+// unversioned format before it.
 func (s *StateReader) RuntimeSerialize(e *avm.ExecutionEngine) bool {
 	buf := new(bytes.Buffer)
 	item := avm.PopStackItem(e)
-	s.SerializeStackItem(item, buf)
+	if !e.IsForked() {
+		serializeLegacyStackItem(item, buf)
+	} else if err := s.SerializeStackItem(item, buf); err != nil {
+		return false
+	}
 	avm.PushData(e, buf.Bytes())
 	return true
 }
@@ -161,117 +224,22 @@ func (s *StateReader) RuntimeSerialize(e *avm.ExecutionEngine) bool {
 func (s *StateReader) RuntimeDerialize (e *avm.ExecutionEngine) bool {
 	data := avm.PopStackItem(e).GetByteArray()
 	reader := bytes.NewReader(data)
+	if !e.IsForked() {
+		item, err := readLegacyStackItem(reader, 0, false)
+		if err != nil {
+			return false
+		}
+		avm.PushData(e, item)
+		return true
+	}
 	item, err := s.DerializeStackItem(reader)
-	if err != nil {
+	if err != nil || reader.Len() != 0 {
 		return false
 	}
 	avm.PushData(e, item)
 	return true
 }
 
-func (s *StateReader) DerializeStackItem(r io.Reader) (datatype.StackItem, error) {
-	var itemType = make([]byte, 1)
-	_, err := r.Read(itemType)
-	if err != nil {
-		return nil, err
-	}
-
-	switch (datatype.StackItemType(itemType[0])) {
-	case datatype.TYPE_ByteArray:
-		bytes, err := common.ReadVarBytes(r, avm.MaxItemSize, "StateReader DerializeStackItem TYPE_ByteArray")
-		if err != nil {
-			return nil, err
-		}
-		return datatype.NewByteArray(bytes), nil
-	case datatype.TYPE_Boolean:
-		data, err := common.ReadUint8(r)
-		if err != nil {
-			return nil, err
-		}
-		bytes := true
-		if data == 0 {
-			bytes = false
-		}
-		if err != nil {
-			return nil, err
-		}
-		return datatype.NewBoolean(bytes), nil
-	case datatype.TYPE_Integer:
-		bytes, err := common.ReadVarBytes(r, avm.MAX_BIGINTEGER, "StateReader DerializeStackItem TYPE_Integer")
-		if err != nil {
-			return nil, err
-		}
-		bint := new(big.Int)
-		bint.SetBytes(bytes)
-		return datatype.NewInteger(bint), nil
-	case datatype.TYPE_Array, datatype.TYPE_Struct:
-		len, err := common.ReadVarUint(r, 0x00)
-		if err != nil {
-			return nil, err
-		}
-		var items = make([]datatype.StackItem, len)
-		for i := 0; i < int(len); i++ {
-			items[i], err = s.DerializeStackItem(r)
-			if err != nil {
-				return nil, err
-			}
-		}
-		return datatype.NewArray(items), nil
-	case datatype.TYPE_Map:
-		dictionary := datatype.NewDictionary()
-		len, err := common.ReadVarUint(r, 0x00)
-		if err != nil {
-			return nil, err
-		}
-		for i := 0; i < int(len); i++ {
-			key, err := s.DerializeStackItem(r)
-			if err != nil {
-				return nil, err
-			}
-			value, err := s.DerializeStackItem(r)
-			if err != nil {
-				return nil, err
-			}
-			dictionary.PutStackItem(key, value)
-		}
-		return dictionary, nil
-	}
-	return nil, errors.New("error type")
-}
-
-func (s *StateReader) SerializeStackItem(item datatype.StackItem, w io.Writer) {
-	switch item.(type) {
-	case *datatype.Boolean:
-		w.Write([]byte{byte(datatype.TYPE_Boolean)})
-		w.Write(item.GetByteArray())
-	case *datatype.Integer:
-		w.Write([]byte{byte(datatype.TYPE_Integer)})
-		common.WriteVarBytes(w, item.GetBigInteger().Bytes())
-	case *datatype.ByteArray:
-		w.Write([]byte{byte(datatype.TYPE_ByteArray)})
-		common.WriteVarBytes(w, item.GetByteArray())
-	case *datatype.GeneralInterface:
-		w.Write([]byte{byte(datatype.TYPE_InteropInterface)})
-		w.Write(item.GetByteArray())
-	case *datatype.Array:
-		w.Write([]byte{byte(datatype.TYPE_Array)})
-		items := item.GetArray()
-		common.WriteVarUint(w, (uint64(len(items))))
-		for i := 0; i < len(items); i++ {
-			s.SerializeStackItem(items[i], w)
-		}
-	case *datatype.Dictionary:
-		dict := item.(*datatype.Dictionary)
-		w.Write([]byte{byte(datatype.TYPE_Map)})
-		dictMap := dict.GetMap()
-		common.WriteVarUint(w, (uint64(len(dictMap))))
-		for key := range dictMap {
-			s.SerializeStackItem(key, w)
-			s.SerializeStackItem(dictMap[key], w)
-		}
-	}
-}
-
 func (s *StateReader) CheckWitnessHash160(engine *avm.ExecutionEngine, programHash []byte) (bool, error) {
 	if engine.GetDataContainer() == nil {
 		return false, errors.New("CheckWitnessHash getDataContainer is null")
@@ -363,7 +331,9 @@ func (s *StateReader) RuntimeCheckWitness(e *avm.ExecutionEngine) bool {
 
 func (s *StateReader) BlockChainGetHeight(e *avm.ExecutionEngine) bool {
 	var i uint32 = 0
-	if blockchain.DefaultChain != nil {
+	if env := s.persistingEnvironment(); env != nil {
+		i = env.Height
+	} else if blockchain.DefaultChain != nil {
 		i = blockchain.DefaultChain.BestChain.Height
 	}
 	avm.PushData(e, i)
@@ -381,18 +351,13 @@ func (s *StateReader) BlockChainGetHeader(e *avm.ExecutionEngine) bool {
 	if l <= 5 {
 		b := new(big.Int)
 		height := b.SetBytes(common.BytesReverse(data)).Int64()
-		if blockchain.DefaultChain != nil {
-			hash, err := blockchain.DefaultChain.GetBlockHash((uint32(height)))
-			if err != nil {
-				return false
-			}
-			header, err = blockchain.DefaultChain.GetHeader(hash)
+		var hash common.Uint256
+		if hash, err = s.getBlockHash(uint32(height)); err == nil {
+			header, err = s.getHeader(hash)
 		}
 	} else if l == 32 {
 		hash, _ := common.Uint256FromBytes(data)
-		if blockchain.DefaultChain != nil {
-			header, err = blockchain.DefaultChain.GetHeader(*hash)
-		}
+		header, err = s.getHeader(*hash)
 	} else {
 		return false
 	}
@@ -413,20 +378,14 @@ func (s *StateReader) BlockChainGetBlock(e *avm.ExecutionEngine) bool {
 	if l <= 5 {
 		b := new(big.Int)
 		height := uint32(b.SetBytes(common.BytesReverse(data)).Int64())
-		if blockchain.DefaultChain != nil {
-			hash, err := blockchain.DefaultChain.GetBlockHash(height)
-			if err != nil {
-				return false
-			}
-			block, err = blockchain.DefaultChain.GetBlockByHash(hash)
+		var hash common.Uint256
+		if hash, err = s.getBlockHash(height); err == nil {
+			block, err = s.getBlock(hash)
 		}
 	} else if l == 32 {
-		hash, err := common.Uint256FromBytes(data)
-		if err != nil {
-			return false
-		}
-		if blockchain.DefaultChain != nil {
-			block, err = blockchain.DefaultChain.GetBlockByHash(*hash)
+		var hash *common.Uint256
+		if hash, err = common.Uint256FromBytes(data); err == nil {
+			block, err = s.getBlock(*hash)
 		}
 	} else {
 		return false
@@ -444,13 +403,11 @@ func (s *StateReader) BlockChainGetTransaction(e *avm.ExecutionEngine) bool {
 	if err != nil {
 		return false
 	}
-	if blockchain.DefaultChain != nil {
-		tx, _, err := blockchain.DefaultChain.GetTransaction(*hash)
-		if err != nil {
-			return false
-		}
-		avm.PushData(e, tx)
+	tx, _, err := s.getTransaction(*hash)
+	if err != nil {
+		return false
 	}
+	avm.PushData(e, tx)
 	return true
 }
 
@@ -460,13 +417,11 @@ func (s *StateReader) BlockchainGetTransactionHeight(e *avm.ExecutionEngine) boo
 	if err != nil {
 		return false
 	}
-	if blockchain.DefaultChain != nil {
-		_, height, err := blockchain.DefaultChain.GetTransaction(*hash)
-		if err != nil {
-			return false
-		}
-		avm.PushData(e, height)
+	_, height, err := s.getTransaction(*hash)
+	if err != nil {
+		return false
 	}
+	avm.PushData(e, height)
 	return true
 }
 
@@ -15,6 +15,7 @@ import (
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/service"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/errors"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
 	ns "github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
 )
@@ -56,7 +57,7 @@ func NewSmartContract(context *Context) (*SmartContract, error) {
 		new(avm.CryptoECDsa),
 		avm.MAXSTEPS,
 		context.CacheCodeTable,
-		context.StateMachine,
+		&context.StateMachine,
 		context.Gas,
 		context.Trigger,
 		false,
@@ -74,28 +75,43 @@ func NewSmartContract(context *Context) (*SmartContract, error) {
 }
 
 func (sc *SmartContract) DeployContract(payload *types.PayloadDeploy) ([]byte, error) {
-	buffer := new(bytes.Buffer)
-	paramBuilder := avm.NewParamsBuider(buffer)
-	var parameterTypes []byte
-	parameterTypes = contract.ContractParameterTypeToByte(payload.Code.ParameterTypes)
-	returnType := byte(payload.Code.ReturnType)
-	paramBuilder.EmitSysCall("Neo.Contract.Create", payload.Code.Code, parameterTypes, returnType, payload.Name,
-		payload.CodeVersion, payload.Author, payload.Email, payload.Description)
-	_ , err := sc.Engine.Call(sc.Caller, sc.CodeHash, paramBuilder.Bytes())
+	_ , err := sc.Engine.Call(sc.Caller, sc.CodeHash, payload.CreateScript())
 	if err != nil {
 		return nil, err
 	}
+	if err := sc.checkState(); err != nil {
+		return nil, err
+	}
 	return sc.Code, nil
 }
 
 func (sc *SmartContract) InvokeContract() (interface{}, error) {
-	_, err := sc.Engine.Call(sc.Caller, sc.CodeHash, sc.Input)
+	err := sc.Execute()
 	if err != nil {
 		return nil, err
 	}
 	return sc.InvokeResult()
 }
 
+// Execute runs the input and leaves the result on the evaluation stack. An
+// error is returned unless the execution ends in HALT, in which case none of
+// its writes should be committed.
+func (sc *SmartContract) Execute() error {
+	_, err := sc.Engine.Call(sc.Caller, sc.CodeHash, sc.Input)
+	if err != nil {
+		return err
+	}
+	return sc.checkState()
+}
+
+func (sc *SmartContract) checkState() error {
+	state := sc.Engine.(*avm.ExecutionEngine).GetState()
+	if state&avm.FAULT == avm.FAULT || state&avm.HALT != avm.HALT {
+		return errors.ErrFault
+	}
+	return nil
+}
+
 func (sc *SmartContract) InvokeResult() (interface{}, error) {
 	engine := sc.Engine.(*avm.ExecutionEngine)
 	if engine.GetEvaluationStack().Count() > 0 && avm.Peek(engine) != nil {
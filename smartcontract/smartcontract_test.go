@@ -0,0 +1,115 @@
+package smartcontract
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	vmerr "github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/errors"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/storage"
+)
+
+type memCache struct {
+	rwSet *storage.RWSet
+}
+
+func newMemCache() *memCache {
+	return &memCache{rwSet: storage.NewRWSet()}
+}
+
+func (c *memCache) GetOrAdd(prefix blockchain.EntryPrefix, key string, value states.IStateValueInterface) (states.IStateValueInterface, error) {
+	if w, ok := c.rwSet.WriteSet[key]; ok && !w.IsDeleted {
+		return w.Item, nil
+	}
+	c.rwSet.Add(prefix, key, value)
+	return value, nil
+}
+
+func (c *memCache) TryGet(prefix blockchain.EntryPrefix, key string) (states.IStateValueInterface, error) {
+	if w, ok := c.rwSet.WriteSet[key]; ok {
+		return w.Item, nil
+	}
+	return nil, errors.New("leveldb: not found")
+}
+
+func (c *memCache) TryDelete(prefix blockchain.EntryPrefix, key string) bool {
+	c.rwSet.Delete(prefix, key)
+	return true
+}
+
+func (c *memCache) GetWriteSet() *storage.RWSet {
+	return c.rwSet
+}
+
+func (c *memCache) FindInternal(prefix blockchain.EntryPrefix, keyPrefix string) database.Iterator {
+	return nil
+}
+
+func execute(script []byte) (*avm.ExecutionEngine, *memCache, error) {
+	cache := newMemCache()
+	sc, _ := NewSmartContract(&Context{
+		StateMachine: *service.NewStateMachine(cache, cache),
+		Input:        script,
+		Gas:          common.Fixed64(0),
+		Trigger:      avm.Application,
+	})
+	err := sc.Execute()
+	return sc.Engine.(*avm.ExecutionEngine), cache, err
+}
+
+func storagePutScript(context string) *avm.ParamsBuilder {
+	builder := avm.NewParamsBuider(new(bytes.Buffer))
+	builder.EmitPushByteArray([]byte("value"))
+	builder.EmitPushByteArray([]byte("key"))
+	builder.EmitSysCall(context)
+	builder.EmitSysCall("Neo.Storage.Put")
+	return builder
+}
+
+func TestExecuteHalt(t *testing.T) {
+	builder := storagePutScript("Neo.Storage.GetContext")
+	engine, cache, err := execute(builder.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, avm.HALT, engine.GetState()&avm.HALT)
+	assert.Equal(t, 1, len(cache.GetWriteSet().WriteSet))
+}
+
+func TestExecuteThrow(t *testing.T) {
+	builder := storagePutScript("Neo.Storage.GetContext")
+	builder.Emit(avm.THROW)
+	engine, _, err := execute(builder.Bytes())
+	assert.Equal(t, vmerr.ErrFault, err)
+	assert.Equal(t, avm.FAULT, engine.GetState()&avm.FAULT)
+
+	builder = storagePutScript("Neo.Storage.GetContext")
+	builder.EmitPushBool(false)
+	builder.Emit(avm.THROWIFNOT)
+	engine, _, err = execute(builder.Bytes())
+	assert.Equal(t, vmerr.ErrFault, err)
+	assert.Equal(t, avm.FAULT, engine.GetState()&avm.FAULT)
+}
+
+func TestExecuteOutOfGas(t *testing.T) {
+	builder := storagePutScript("Neo.Storage.GetContext")
+	// jump to itself until the gas runs out
+	script := append(builder.Bytes(), byte(avm.JMP), 0x00, 0x00)
+	engine, _, err := execute(script)
+	assert.Equal(t, vmerr.ErrOutOfGas, err)
+	assert.Equal(t, avm.FAULT, engine.GetState()&avm.FAULT)
+}
+
+func TestExecuteSysCallFail(t *testing.T) {
+	builder := storagePutScript("Neo.Storage.GetReadOnlyContext")
+	engine, _, err := execute(builder.Bytes())
+	assert.Equal(t, vmerr.ErrFault, err)
+	assert.Equal(t, avm.FAULT, engine.GetState()&avm.FAULT)
+}
@@ -21,6 +21,7 @@ type DBCache interface {
 type CloneCache struct {
 	innerCache DBCache
 	dbCache    DBCache
+	snapshots  []*Snapshot
 }
 
 func NewCloneDBCache(innerCache DBCache, dbCache DBCache) *CloneCache {
@@ -30,21 +31,54 @@ func NewCloneDBCache(innerCache DBCache, dbCache DBCache) *CloneCache {
 	}
 }
 
+// GetInnerCache returns the cache receiving the writes of the executing
+// contract, which is the innermost snapshot if there is one.
 func (cloneCache *CloneCache) GetInnerCache() DBCache {
+	if n := len(cloneCache.snapshots); n > 0 {
+		return cloneCache.snapshots[n-1]
+	}
 	return cloneCache.innerCache
 }
 
+// PushSnapshot starts a child snapshot for a contract call.
+func (cloneCache *CloneCache) PushSnapshot() {
+	cloneCache.snapshots = append(cloneCache.snapshots, NewSnapshot(cloneCache.GetInnerCache()))
+}
+
+// PopSnapshot ends the innermost snapshot, its writes are merged into the
+// parent if commit is true and dropped otherwise.
+func (cloneCache *CloneCache) PopSnapshot(commit bool) {
+	n := len(cloneCache.snapshots)
+	if n == 0 {
+		return
+	}
+	snapshot := cloneCache.snapshots[n-1]
+	cloneCache.snapshots = cloneCache.snapshots[:n-1]
+	if commit {
+		snapshot.Commit()
+	}
+}
+
+// Commit merges the remaining snapshots and the inner cache into dbCache.
 func (cloneCache *CloneCache) Commit() {
-	for _, v := range cloneCache.innerCache.GetWriteSet().WriteSet {
-		if v.IsDeleted {
-			cloneCache.innerCache.GetWriteSet().Delete(v.Prefix, v.Key)
-		} else {
-			cloneCache.innerCache.GetWriteSet().Add(v.Prefix, v.Key, v.Item)
-		}
+	for len(cloneCache.snapshots) > 0 {
+		cloneCache.PopSnapshot(true)
+	}
+	if cloneCache.innerCache == cloneCache.dbCache {
+		return
+	}
+	writeSet := cloneCache.dbCache.GetWriteSet().WriteSet
+	for k, v := range cloneCache.innerCache.GetWriteSet().WriteSet {
+		writeSet[k] = v
 	}
 }
 
 func (cloneCache *CloneCache) TryGet(prefix blockchain.EntryPrefix, key string) (states.IStateValueInterface, error) {
+	for i := len(cloneCache.snapshots) - 1; i >= 0; i-- {
+		if v, ok := cloneCache.snapshots[i].rwSet.WriteSet[key]; ok {
+			return v.Item, nil
+		}
+	}
 	if v, ok := cloneCache.innerCache.GetWriteSet().WriteSet[key]; ok {
 		return v.Item, nil
 	} else {
@@ -54,12 +88,10 @@ func (cloneCache *CloneCache) TryGet(prefix blockchain.EntryPrefix, key string)
 
 func (cloneCache *CloneCache) TryDelete(prefix blockchain.EntryPrefix, hash common.Uint168) bool {
 	keyStr := string(params.UInt168ToUInt160(&hash))
-	cloneCache.innerCache.GetWriteSet().Delete(prefix, keyStr)
-	result := cloneCache.dbCache.TryDelete(prefix, keyStr)
-	return result
+	return cloneCache.GetInnerCache().TryDelete(prefix, keyStr)
 }
 
 func (cloneCache* CloneCache) Find(prefix blockchain.EntryPrefix, keyPreFix string) database.Iterator {
-	iterator := cloneCache.innerCache.FindInternal(prefix, keyPreFix)
+	iterator := cloneCache.GetInnerCache().FindInternal(prefix, keyPreFix)
 	return iterator
-}
\ No newline at end of file
+}
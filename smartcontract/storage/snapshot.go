@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/enumerators"
+)
+
+// Snapshot is a layer of writes on top of a parent cache. Reads fall through
+// to the parent, and the writes reach the parent only when the snapshot is
+// committed, so the writes of a failed contract call can be dropped.
+type Snapshot struct {
+	parent DBCache
+	rwSet  *RWSet
+}
+
+func NewSnapshot(parent DBCache) *Snapshot {
+	return &Snapshot{
+		parent: parent,
+		rwSet:  NewRWSet(),
+	}
+}
+
+func (snapshot *Snapshot) GetOrAdd(prefix blockchain.EntryPrefix, key string, value states.IStateValueInterface) (states.IStateValueInterface, error) {
+	if v, ok := snapshot.rwSet.WriteSet[key]; ok {
+		if v.IsDeleted {
+			v.Item = value
+			v.IsDeleted = false
+		}
+		return v.Item, nil
+	}
+	item, err := snapshot.parent.TryGet(prefix, key)
+	if err != nil && err.Error() != ("leveldb: not found") {
+		return nil, err
+	}
+	if item == nil {
+		item = value
+	} else if item, err = copyItem(item); err != nil {
+		return nil, err
+	}
+	snapshot.rwSet.WriteSet[key] = &Write{
+		Prefix: prefix,
+		Key:    key,
+		Item:   item,
+	}
+	return item, nil
+}
+
+// copyItem returns a copy of an item of the parent, so the changes made to it
+// by the caller stay in the snapshot until it is committed.
+func copyItem(item states.IStateValueInterface) (states.IStateValueInterface, error) {
+	buf := new(bytes.Buffer)
+	if err := item.Serialize(buf); err != nil {
+		return nil, err
+	}
+	clone := reflect.New(reflect.TypeOf(item).Elem()).Interface().(states.IStateValueInterface)
+	if err := clone.Deserialize(buf); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+func (snapshot *Snapshot) TryGet(prefix blockchain.EntryPrefix, key string) (states.IStateValueInterface, error) {
+	if v, ok := snapshot.rwSet.WriteSet[key]; ok {
+		return v.Item, nil
+	}
+	return snapshot.parent.TryGet(prefix, key)
+}
+
+func (snapshot *Snapshot) TryDelete(prefix blockchain.EntryPrefix, key string) bool {
+	snapshot.rwSet.Delete(prefix, key)
+	return true
+}
+
+func (snapshot *Snapshot) GetWriteSet() *RWSet {
+	return snapshot.rwSet
+}
+
+// FindInternal lists the entries of the parent with the writes of this
+// snapshot applied on top of them.
+func (snapshot *Snapshot) FindInternal(prefix blockchain.EntryPrefix, keyPrefix string) database.Iterator {
+	return MergeWrites(snapshot.rwSet, prefix, keyPrefix, snapshot.parent.FindInternal(prefix, keyPrefix))
+}
+
+// MergeWrites lists the entries of iter with the writes of rwSet under prefix
+// and keyPrefix applied on top of them, written entries replace the entries
+// of iter and deleted ones are left out.
+func MergeWrites(rwSet *RWSet, prefix blockchain.EntryPrefix, keyPrefix string, iter database.Iterator) database.Iterator {
+	writes := make(map[string]*Write)
+	for key, v := range rwSet.WriteSet {
+		if v.Prefix == prefix && strings.HasPrefix(key, keyPrefix) {
+			writes[key] = v
+		}
+	}
+	if len(writes) == 0 {
+		return iter
+	}
+
+	iteratorList := enumerators.NewListIterator()
+	if iter != nil {
+		for iter.Next() {
+			if _, ok := writes[string(iter.Key()[1:])]; ok {
+				continue
+			}
+			iteratorList.Add(iter.Key(), iter.Value())
+		}
+		iter.Release()
+	}
+	keys := make([]string, 0, len(writes))
+	for key, v := range writes {
+		if !v.IsDeleted {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := writes[key].Item
+		if item, ok := value.(*states.StorageItem); ok {
+			iteratorList.Add(append([]byte{byte(prefix)}, key...), item.Value)
+		} else {
+			iteratorList.Add(append([]byte{byte(prefix)}, key...), value)
+		}
+	}
+	return iteratorList
+}
+
+// Commit merges the writes of the snapshot into its parent.
+func (snapshot *Snapshot) Commit() {
+	writeSet := snapshot.parent.GetWriteSet().WriteSet
+	for key, v := range snapshot.rwSet.WriteSet {
+		writeSet[key] = v
+	}
+	snapshot.rwSet = NewRWSet()
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+type memCache struct {
+	rwSet *RWSet
+}
+
+func (c *memCache) GetOrAdd(prefix blockchain.EntryPrefix, key string, value states.IStateValueInterface) (states.IStateValueInterface, error) {
+	if w, ok := c.rwSet.WriteSet[key]; ok && !w.IsDeleted {
+		return w.Item, nil
+	}
+	c.rwSet.Add(prefix, key, value)
+	return value, nil
+}
+
+func (c *memCache) TryGet(prefix blockchain.EntryPrefix, key string) (states.IStateValueInterface, error) {
+	if w, ok := c.rwSet.WriteSet[key]; ok {
+		return w.Item, nil
+	}
+	return nil, errors.New("leveldb: not found")
+}
+
+func (c *memCache) TryDelete(prefix blockchain.EntryPrefix, key string) bool {
+	c.rwSet.Delete(prefix, key)
+	return true
+}
+
+func (c *memCache) GetWriteSet() *RWSet {
+	return c.rwSet
+}
+
+func (c *memCache) FindInternal(prefix blockchain.EntryPrefix, keyPrefix string) database.Iterator {
+	return nil
+}
+
+func TestCloneCacheSnapshots(t *testing.T) {
+	cache := &memCache{rwSet: NewRWSet()}
+	cache.GetWriteSet().Add(blockchain.ST_Storage, "a", states.NewStorageItem([]byte{1}))
+	cloneCache := NewCloneDBCache(cache, cache)
+
+	// a failed call drops its writes
+	cloneCache.PushSnapshot()
+	cloneCache.GetInnerCache().GetWriteSet().Add(blockchain.ST_Storage, "b", states.NewStorageItem([]byte{2}))
+	item, err := cloneCache.TryGet(blockchain.ST_Storage, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, item.(*states.StorageItem).Value)
+	cloneCache.PopSnapshot(false)
+	_, err = cloneCache.TryGet(blockchain.ST_Storage, "b")
+	assert.Error(t, err)
+
+	// a successful inner call merges into a caller which fails later
+	cloneCache.PushSnapshot()
+	cloneCache.PushSnapshot()
+	cloneCache.GetInnerCache().TryDelete(blockchain.ST_Storage, "a")
+	cloneCache.PopSnapshot(true)
+	item, err = cloneCache.TryGet(blockchain.ST_Storage, "a")
+	assert.NoError(t, err)
+	assert.Nil(t, item)
+	cloneCache.PopSnapshot(false)
+	item, err = cloneCache.TryGet(blockchain.ST_Storage, "a")
+	assert.NoError(t, err)
+	assert.NotNil(t, item)
+
+	// a failed call changing an item it got from its caller leaves the
+	// item of the caller as it is
+	cloneCache.PushSnapshot()
+	item, err = cloneCache.GetInnerCache().GetOrAdd(blockchain.ST_Storage, "a", states.NewStorageItem([]byte{4}))
+	assert.NoError(t, err)
+	item.(*states.StorageItem).Value = []byte{5}
+	cloneCache.PopSnapshot(false)
+	item, err = cloneCache.TryGet(blockchain.ST_Storage, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, item.(*states.StorageItem).Value)
+
+	// successful calls reach the transaction cache
+	cloneCache.PushSnapshot()
+	cloneCache.PushSnapshot()
+	cloneCache.GetInnerCache().GetWriteSet().Add(blockchain.ST_Storage, "c", states.NewStorageItem([]byte{3}))
+	cloneCache.PopSnapshot(true)
+	cloneCache.PopSnapshot(true)
+	assert.Equal(t, 2, len(cache.GetWriteSet().WriteSet))
+	assert.Equal(t, []byte{3}, cache.GetWriteSet().WriteSet["c"].Item.(*states.StorageItem).Value)
+}
@@ -0,0 +1,174 @@
+package trie
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+const (
+	leafNodeType      byte = 0x00
+	extensionNodeType byte = 0x01
+	branchNodeType    byte = 0x02
+)
+
+// node is one of *leafNode, *extensionNode and *branchNode. Paths are
+// sequences of nibbles, and children are referenced by the hash of their
+// encoding.
+type node interface {
+	encode() []byte
+}
+
+type leafNode struct {
+	path  []byte
+	value []byte
+}
+
+type extensionNode struct {
+	path  []byte
+	child common.Uint256
+}
+
+type branchNode struct {
+	children [16]*common.Uint256
+	value    []byte
+}
+
+func (n *leafNode) encode() []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(leafNodeType)
+	common.WriteVarBytes(buf, n.path)
+	common.WriteVarBytes(buf, n.value)
+	return buf.Bytes()
+}
+
+func (n *extensionNode) encode() []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(extensionNodeType)
+	common.WriteVarBytes(buf, n.path)
+	n.child.Serialize(buf)
+	return buf.Bytes()
+}
+
+func (n *branchNode) encode() []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(branchNodeType)
+	for _, child := range n.children {
+		if child == nil {
+			buf.WriteByte(0)
+			continue
+		}
+		buf.WriteByte(1)
+		child.Serialize(buf)
+	}
+	if n.value == nil {
+		buf.WriteByte(0)
+	} else {
+		buf.WriteByte(1)
+		common.WriteVarBytes(buf, n.value)
+	}
+	return buf.Bytes()
+}
+
+func decodeNode(data []byte) (node, error) {
+	r := bytes.NewReader(data)
+	nodeType, err := common.ReadUint8(r)
+	if err != nil {
+		return nil, err
+	}
+	switch nodeType {
+	case leafNodeType:
+		path, err := readPath(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := common.ReadVarBytes(r, common.MaxVarStringLength, "trie leaf value")
+		if err != nil {
+			return nil, err
+		}
+		return &leafNode{path: path, value: value}, nil
+	case extensionNodeType:
+		path, err := readPath(r)
+		if err != nil {
+			return nil, err
+		}
+		n := &extensionNode{path: path}
+		if err := n.child.Deserialize(r); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case branchNodeType:
+		n := new(branchNode)
+		for i := range n.children {
+			flag, err := common.ReadUint8(r)
+			if err != nil {
+				return nil, err
+			}
+			if flag == 0 {
+				continue
+			}
+			child := new(common.Uint256)
+			if err := child.Deserialize(r); err != nil {
+				return nil, err
+			}
+			n.children[i] = child
+		}
+		flag, err := common.ReadUint8(r)
+		if err != nil {
+			return nil, err
+		}
+		if flag != 0 {
+			n.value, err = common.ReadVarBytes(r, common.MaxVarStringLength, "trie branch value")
+			if err != nil {
+				return nil, err
+			}
+		}
+		return n, nil
+	}
+	return nil, errors.New("unknown trie node type")
+}
+
+func readPath(r io.Reader) ([]byte, error) {
+	path, err := common.ReadVarBytes(r, common.MaxVarStringLength, "trie node path")
+	if err != nil {
+		return nil, err
+	}
+	for _, nibble := range path {
+		if nibble > 0x0f {
+			return nil, errors.New("invalid trie node path")
+		}
+	}
+	return path, nil
+}
+
+func hashNode(data []byte) common.Uint256 {
+	first := sha256.Sum256(data)
+	return common.Uint256(sha256.Sum256(first[:]))
+}
+
+func toNibbles(key []byte) []byte {
+	nibbles := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	return nibbles
+}
+
+func commonPrefix(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func concat(paths ...[]byte) []byte {
+	var path []byte
+	for _, p := range paths {
+		path = append(path, p...)
+	}
+	return path
+}
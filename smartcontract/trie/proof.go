@@ -0,0 +1,28 @@
+package trie
+
+import (
+	"errors"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+)
+
+// VerifyProof checks proof against root and returns the value of key, a nil
+// value means the proof shows key is absent from the trie. An error is
+// returned if the proof does not lead from root to the position of key.
+func VerifyProof(root common.Uint256, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := make(map[common.Uint256][]byte, len(proof))
+	for _, data := range proof {
+		nodes[hashNode(data)] = data
+	}
+	var hash *common.Uint256
+	if root != (common.Uint256{}) {
+		hash = &root
+	}
+	return walkPath(hash, toNibbles(key), func(hash common.Uint256) (node, error) {
+		data, ok := nodes[hash]
+		if !ok {
+			return nil, errors.New("proof is missing node " + hash.String())
+		}
+		return decodeNode(data)
+	})
+}
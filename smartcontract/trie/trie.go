@@ -0,0 +1,340 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+var ErrNodeNotFound = errors.New("trie node not found")
+
+// Database is the store the nodes of a trie are read from.
+type Database interface {
+	Get(key []byte) ([]byte, error)
+}
+
+// Batch is the store the new nodes of a trie are written to.
+type Batch interface {
+	Put(key []byte, value []byte) error
+}
+
+// Trie is a Merkle Patricia trie whose nodes are persisted by their hash, so
+// the nodes of the previous roots are never overwritten and every historical
+// root remains readable and provable.
+type Trie struct {
+	db    Database
+	root  *common.Uint256
+	dirty map[common.Uint256][]byte
+}
+
+// New opens the trie of root, an empty root opens an empty trie.
+func New(root common.Uint256, db Database) *Trie {
+	t := &Trie{db: db, dirty: make(map[common.Uint256][]byte)}
+	if root != (common.Uint256{}) {
+		t.root = &root
+	}
+	return t
+}
+
+// Hash returns the root of the trie, the root of an empty trie is zero.
+func (t *Trie) Hash() common.Uint256 {
+	if t.root == nil {
+		return common.Uint256{}
+	}
+	return *t.root
+}
+
+func (t *Trie) Get(key []byte) ([]byte, error) {
+	value, _, err := t.walk(key, false)
+	return value, err
+}
+
+// Prove returns the encoded nodes on the path of key, they prove either the
+// value of key or that key is absent from the trie.
+func (t *Trie) Prove(key []byte) ([][]byte, error) {
+	_, proof, err := t.walk(key, true)
+	return proof, err
+}
+
+func (t *Trie) walk(key []byte, prove bool) ([]byte, [][]byte, error) {
+	var proof [][]byte
+	value, err := walkPath(t.root, toNibbles(key), func(hash common.Uint256) (node, error) {
+		data, err := t.getNode(hash)
+		if err != nil {
+			return nil, err
+		}
+		if prove {
+			proof = append(proof, data)
+		}
+		return decodeNode(data)
+	})
+	return value, proof, err
+}
+
+func walkPath(hash *common.Uint256, path []byte, load func(common.Uint256) (node, error)) ([]byte, error) {
+	for hash != nil {
+		n, err := load(*hash)
+		if err != nil {
+			return nil, err
+		}
+		switch n := n.(type) {
+		case *leafNode:
+			if bytes.Equal(n.path, path) {
+				return n.value, nil
+			}
+			return nil, nil
+		case *extensionNode:
+			if !bytes.HasPrefix(path, n.path) {
+				return nil, nil
+			}
+			path = path[len(n.path):]
+			hash = &n.child
+		case *branchNode:
+			if len(path) == 0 {
+				return n.value, nil
+			}
+			hash = n.children[path[0]]
+			path = path[1:]
+		}
+	}
+	return nil, nil
+}
+
+func (t *Trie) Put(key []byte, value []byte) error {
+	if value == nil {
+		value = []byte{}
+	}
+	root, err := t.insert(t.root, toNibbles(key), value)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+func (t *Trie) Delete(key []byte) error {
+	root, err := t.delete(t.root, toNibbles(key))
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+// Commit writes the nodes reachable from the current root which are not
+// persisted yet into batch.
+func (t *Trie) Commit(batch Batch) error {
+	if t.root != nil {
+		if err := t.commitNode(*t.root, batch); err != nil {
+			return err
+		}
+	}
+	t.dirty = make(map[common.Uint256][]byte)
+	return nil
+}
+
+func (t *Trie) commitNode(hash common.Uint256, batch Batch) error {
+	data, ok := t.dirty[hash]
+	if !ok {
+		return nil
+	}
+	delete(t.dirty, hash)
+	n, err := decodeNode(data)
+	if err != nil {
+		return err
+	}
+	switch n := n.(type) {
+	case *extensionNode:
+		if err := t.commitNode(n.child, batch); err != nil {
+			return err
+		}
+	case *branchNode:
+		for _, child := range n.children {
+			if child == nil {
+				continue
+			}
+			if err := t.commitNode(*child, batch); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.Put(NodeKey(hash), data)
+}
+
+func (t *Trie) insert(hash *common.Uint256, path []byte, value []byte) (*common.Uint256, error) {
+	if hash == nil {
+		return t.putNode(&leafNode{path: path, value: value}), nil
+	}
+	n, err := t.loadNode(*hash)
+	if err != nil {
+		return nil, err
+	}
+	switch n := n.(type) {
+	case *leafNode:
+		if bytes.Equal(n.path, path) {
+			return t.putNode(&leafNode{path: path, value: value}), nil
+		}
+		prefix := commonPrefix(n.path, path)
+		branch := new(branchNode)
+		t.setBranchLeaf(branch, n.path[prefix:], n.value)
+		t.setBranchLeaf(branch, path[prefix:], value)
+		return t.wrapExtension(path[:prefix], t.putNode(branch)), nil
+	case *extensionNode:
+		prefix := commonPrefix(n.path, path)
+		if prefix == len(n.path) {
+			child, err := t.insert(&n.child, path[prefix:], value)
+			if err != nil {
+				return nil, err
+			}
+			return t.putNode(&extensionNode{path: n.path, child: *child}), nil
+		}
+		branch := new(branchNode)
+		child := n.child
+		if prefix+1 < len(n.path) {
+			child = *t.putNode(&extensionNode{path: n.path[prefix+1:], child: n.child})
+		}
+		branch.children[n.path[prefix]] = &child
+		t.setBranchLeaf(branch, path[prefix:], value)
+		return t.wrapExtension(path[:prefix], t.putNode(branch)), nil
+	case *branchNode:
+		branch := *n
+		if len(path) == 0 {
+			branch.value = value
+			return t.putNode(&branch), nil
+		}
+		child, err := t.insert(n.children[path[0]], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		branch.children[path[0]] = child
+		return t.putNode(&branch), nil
+	}
+	return nil, errors.New("unknown trie node")
+}
+
+func (t *Trie) setBranchLeaf(branch *branchNode, path []byte, value []byte) {
+	if len(path) == 0 {
+		branch.value = value
+		return
+	}
+	branch.children[path[0]] = t.putNode(&leafNode{path: path[1:], value: value})
+}
+
+func (t *Trie) wrapExtension(path []byte, child *common.Uint256) *common.Uint256 {
+	if len(path) == 0 {
+		return child
+	}
+	return t.putNode(&extensionNode{path: path, child: *child})
+}
+
+func (t *Trie) delete(hash *common.Uint256, path []byte) (*common.Uint256, error) {
+	if hash == nil {
+		return nil, nil
+	}
+	n, err := t.loadNode(*hash)
+	if err != nil {
+		return nil, err
+	}
+	switch n := n.(type) {
+	case *leafNode:
+		if bytes.Equal(n.path, path) {
+			return nil, nil
+		}
+		return hash, nil
+	case *extensionNode:
+		if !bytes.HasPrefix(path, n.path) {
+			return hash, nil
+		}
+		child, err := t.delete(&n.child, path[len(n.path):])
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			return nil, nil
+		}
+		return t.mergePath(n.path, child)
+	case *branchNode:
+		branch := *n
+		if len(path) == 0 {
+			branch.value = nil
+		} else {
+			child, err := t.delete(n.children[path[0]], path[1:])
+			if err != nil {
+				return nil, err
+			}
+			branch.children[path[0]] = child
+		}
+		return t.collapseBranch(&branch)
+	}
+	return nil, errors.New("unknown trie node")
+}
+
+// collapseBranch keeps the trie canonical after a deletion, a branch left
+// with a single child or only a value is replaced by a shorter node.
+func (t *Trie) collapseBranch(branch *branchNode) (*common.Uint256, error) {
+	index, count := -1, 0
+	for i, child := range branch.children {
+		if child != nil {
+			index = i
+			count++
+		}
+	}
+	switch {
+	case count == 0 && branch.value == nil:
+		return nil, nil
+	case count == 0:
+		return t.putNode(&leafNode{path: []byte{}, value: branch.value}), nil
+	case count == 1 && branch.value == nil:
+		return t.mergePath([]byte{byte(index)}, branch.children[index])
+	}
+	return t.putNode(branch), nil
+}
+
+// mergePath prepends path to the node of hash.
+func (t *Trie) mergePath(path []byte, hash *common.Uint256) (*common.Uint256, error) {
+	n, err := t.loadNode(*hash)
+	if err != nil {
+		return nil, err
+	}
+	switch n := n.(type) {
+	case *leafNode:
+		return t.putNode(&leafNode{path: concat(path, n.path), value: n.value}), nil
+	case *extensionNode:
+		return t.putNode(&extensionNode{path: concat(path, n.path), child: n.child}), nil
+	}
+	return t.putNode(&extensionNode{path: path, child: *hash}), nil
+}
+
+func (t *Trie) putNode(n node) *common.Uint256 {
+	data := n.encode()
+	hash := hashNode(data)
+	t.dirty[hash] = data
+	return &hash
+}
+
+func (t *Trie) getNode(hash common.Uint256) ([]byte, error) {
+	if data, ok := t.dirty[hash]; ok {
+		return data, nil
+	}
+	data, err := t.db.Get(NodeKey(hash))
+	if err != nil {
+		return nil, ErrNodeNotFound
+	}
+	return data, nil
+}
+
+func (t *Trie) loadNode(hash common.Uint256) (node, error) {
+	data, err := t.getNode(hash)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNode(data)
+}
+
+// NodeKey is the database key of the trie node of hash.
+func NodeKey(hash common.Uint256) []byte {
+	return append([]byte{byte(states.ST_TrieNode)}, hash[:]...)
+}
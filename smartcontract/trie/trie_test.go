@@ -0,0 +1,124 @@
+package trie
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type memDB map[string][]byte
+
+func (db memDB) Get(key []byte) ([]byte, error) {
+	if value, ok := db[string(key)]; ok {
+		return value, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (db memDB) Put(key []byte, value []byte) error {
+	db[string(key)] = value
+	return nil
+}
+
+var testItems = []struct{ key, value string }{
+	{"abc", "1"},
+	{"abd", "2"},
+	{"ab", "3"},
+	{"b", "4"},
+	{"abcdef", "5"},
+}
+
+func TestTriePutDelete(t *testing.T) {
+	forward := New(common.Uint256{}, memDB{})
+	for _, item := range testItems {
+		assert.NoError(t, forward.Put([]byte(item.key), []byte(item.value)))
+	}
+	backward := New(common.Uint256{}, memDB{})
+	for i := len(testItems) - 1; i >= 0; i-- {
+		item := testItems[i]
+		assert.NoError(t, backward.Put([]byte(item.key), []byte(item.value)))
+	}
+	assert.Equal(t, forward.Hash(), backward.Hash())
+
+	for _, item := range testItems {
+		value, err := forward.Get([]byte(item.key))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(item.value), value)
+	}
+	value, err := forward.Get([]byte("abce"))
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	partial := New(common.Uint256{}, memDB{})
+	for _, item := range testItems[:3] {
+		assert.NoError(t, partial.Put([]byte(item.key), []byte(item.value)))
+	}
+	for _, item := range testItems[3:] {
+		assert.NoError(t, forward.Delete([]byte(item.key)))
+	}
+	assert.Equal(t, partial.Hash(), forward.Hash())
+
+	for _, item := range testItems[:3] {
+		assert.NoError(t, forward.Delete([]byte(item.key)))
+	}
+	assert.Equal(t, common.Uint256{}, forward.Hash())
+}
+
+func TestTrieCommit(t *testing.T) {
+	db := memDB{}
+	tr := New(common.Uint256{}, db)
+	for _, item := range testItems {
+		assert.NoError(t, tr.Put([]byte(item.key), []byte(item.value)))
+	}
+	assert.NoError(t, tr.Commit(db))
+	root := tr.Hash()
+
+	assert.NoError(t, tr.Put([]byte("abc"), []byte("6")))
+	assert.NoError(t, tr.Commit(db))
+
+	old := New(root, db)
+	value, err := old.Get([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	current := New(tr.Hash(), db)
+	value, err = current.Get([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("6"), value)
+}
+
+func TestVerifyProof(t *testing.T) {
+	db := memDB{}
+	tr := New(common.Uint256{}, db)
+	for _, item := range testItems {
+		assert.NoError(t, tr.Put([]byte(item.key), []byte(item.value)))
+	}
+	assert.NoError(t, tr.Commit(db))
+	root := tr.Hash()
+
+	proof, err := New(root, db).Prove([]byte("abd"))
+	assert.NoError(t, err)
+	value, err := VerifyProof(root, []byte("abd"), proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+
+	proof, err = New(root, db).Prove([]byte("abe"))
+	assert.NoError(t, err)
+	value, err = VerifyProof(root, []byte("abe"), proof)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	proof, err = New(root, db).Prove([]byte("abd"))
+	assert.NoError(t, err)
+	last := proof[len(proof)-1]
+	tampered := append([]byte{}, last...)
+	tampered[len(tampered)-1] ^= 0xff
+	proof[len(proof)-1] = tampered
+	_, err = VerifyProof(root, []byte("abd"), proof)
+	assert.Error(t, err)
+
+	_, err = VerifyProof(root, []byte("abd"), nil)
+	assert.Error(t, err)
+}
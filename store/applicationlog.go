@@ -0,0 +1,67 @@
+package store
+
+import (
+	"bytes"
+
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+func applicationLogKey(txID common.Uint256) []byte {
+	return append([]byte{byte(states.ST_ApplicationLog)}, txID.Bytes()...)
+}
+
+func (c *LedgerStore) GetApplicationLog(txID common.Uint256) (*states.ApplicationLog, error) {
+	data, err := c.Get(applicationLogKey(txID))
+	if err != nil {
+		return nil, err
+	}
+	appLog := new(states.ApplicationLog)
+	if err := appLog.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return appLog, nil
+}
+
+// newApplicationLog builds the receipt of an execution. The engine is nil if
+// the transaction failed before the contract was executed, and err is the
+// error that made the execution fail.
+func newApplicationLog(txID common.Uint256, engine *avm.ExecutionEngine, stateMachine *service.StateMachine,
+	err error) *states.ApplicationLog {
+	appLog := &states.ApplicationLog{TxID: txID, VMState: byte(avm.FAULT)}
+	if stateMachine != nil {
+		appLog.Notifications = stateMachine.Notifications
+		appLog.Logs = stateMachine.Logs
+	}
+	if engine != nil {
+		appLog.VMState = byte(engine.GetState())
+		appLog.GasConsumed = common.Fixed64(engine.GetGasConsumed())
+		stack := engine.GetEvaluationStack()
+		for i := 0; i < stack.Count(); i++ {
+			// Items that can not be serialized are recorded as empty.
+			var item []byte
+			buf := new(bytes.Buffer)
+			if err := stateMachine.SerializeStackItem(stack.Peek(i).(datatype.StackItem), buf); err == nil {
+				item = buf.Bytes()
+			}
+			appLog.Stack = append(appLog.Stack, item)
+		}
+	}
+	if err != nil {
+		appLog.VMState = byte(avm.FAULT)
+		appLog.Fault = err.Error()
+	} else if appLog.VMState&byte(avm.FAULT) == byte(avm.FAULT) {
+		appLog.Fault = "contract execution failed"
+	}
+	return appLog
+}
+
+func (c *LedgerStore) persistApplicationLog(batch database.Batch, appLog *states.ApplicationLog) error {
+	return batch.Put(applicationLogKey(appLog.TxID), appLog.Bytes())
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+)
+
+func TestFailureReceipt(t *testing.T) {
+	txID := common.Uint256{1, 2, 3}
+	appLog := newApplicationLog(txID, nil, nil, errors.New("unknown contract"))
+	assert.Equal(t, txID, appLog.TxID)
+	assert.Equal(t, byte(avm.FAULT), appLog.VMState)
+	assert.Equal(t, "unknown contract", appLog.Fault)
+	assert.Equal(t, common.Fixed64(0), appLog.GasConsumed)
+	assert.Equal(t, appLog.Bytes(), newApplicationLog(txID, nil, nil, errors.New("unknown contract")).Bytes())
+
+	// a failed execution is persisted as a receipt with the block
+	c, db := newTestLedgerStore()
+	invoke := invokeTx(&common.Uint168{0x1c, 1}, 1, "")
+	_, err := persistBlock(c, 1, invoke)
+	assert.NoError(t, err)
+	appLog, err = c.GetApplicationLog(invoke.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, byte(avm.FAULT), appLog.VMState)
+	_, err = c.GetStateRoot(1)
+	assert.NoError(t, err)
+
+	// a failure of the node aborts the block
+	snapshot := db.snapshot()
+	db.err = errors.New("disk failure")
+	_, err = persistBlock(c, 2, invokeTx(&common.Uint168{0x1c, 1}, 2, ""))
+	assert.Error(t, err)
+	db.err = nil
+	assert.Equal(t, snapshot, db.data)
+}
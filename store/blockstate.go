@@ -0,0 +1,241 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+	"github.com/elastos/Elastos.ELA.SideChain/events"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/trie"
+)
+
+var contractStateKey = []byte{byte(states.SYS_ContractState)}
+
+// StorageTrieFlag is present once the storage trie holds all the contract
+// storage. The storage persisted before the trie was introduced is not in the
+// trie, so such a chain builds it by reindexing the contracts from genesis.
+const StorageTrieFlag = "StorageTrieFlag"
+
+// blockState accumulates the contract state changed by the transactions of a
+// block, it is written into the block batch once all of them are persisted so
+// the contract state is committed atomically with the block.
+type blockState struct {
+	cache   *blockchain.DBCache
+	indexes map[common.Uint168]*states.ContractIndex
+	events  []*states.ContractEvent
+
+	// balances are the NEP-5 balances changed by the block by key.
+	balances  map[string]*states.NEP5Balance
+	transfers []*states.NEP5Transfer
+
+	// nep11Balances are the NEP-11 balances changed by the block by key.
+	nep11Balances  map[string]*nep11Balance
+	nep11Transfers []*states.NEP11Transfer
+
+	// manifests are the contract manifests changed by the block, a nil
+	// manifest is deleted.
+	manifests map[common.Uint168][]byte
+
+	// published are the events of the block, they are published once the
+	// block is committed.
+	published []*pendingEvent
+}
+
+func (state *blockState) notify(eventType events.EventType, data interface{}) {
+	state.published = append(state.published, &pendingEvent{eventType: eventType, data: data})
+}
+
+func (c *LedgerStore) newBlockState() *blockState {
+	return &blockState{
+		cache:         blockchain.NewDBCache(c),
+		indexes:       make(map[common.Uint168]*states.ContractIndex),
+		balances:      make(map[string]*states.NEP5Balance),
+		nep11Balances: make(map[string]*nep11Balance),
+		manifests:     make(map[common.Uint168][]byte),
+	}
+}
+
+func (c *LedgerStore) commitBlockState(batch database.Batch, state *blockState, b *side.Block) error {
+	root, err := c.computeStateRoot(state, b)
+	if err != nil {
+		return err
+	}
+	if root.StorageRoot, err = c.updateStorageTrie(batch, state, root.Height); err != nil {
+		return err
+	}
+	if err := batch.Put(states.StateRootKey(b.Height), root.Bytes()); err != nil {
+		return err
+	}
+	state.cache.CommitTo(batch)
+	for codeHash, index := range state.indexes {
+		if index == nil {
+			continue
+		}
+		hash := codeHash
+		if err := batch.Put(contractIndexKey(&hash), index.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := persistContractManifests(batch, state); err != nil {
+		return err
+	}
+
+	if err := persistContractEvents(batch, state, b.Height); err != nil {
+		return err
+	}
+	if err := persistNEP5Transfers(batch, state, b.Height); err != nil {
+		return err
+	}
+	if err := persistNEP11Transfers(batch, state, b.Height); err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := common.WriteUint32(buf, b.Height); err != nil {
+		return err
+	}
+	hash := b.Hash()
+	if err := hash.Serialize(buf); err != nil {
+		return err
+	}
+	return batch.Put(contractStateKey, buf.Bytes())
+}
+
+func (c *LedgerStore) computeStateRoot(state *blockState, b *side.Block) (*states.StateRoot, error) {
+	var prevRoot common.Uint256
+	if b.Height > 0 {
+		prev, err := c.GetStateRoot(b.Height - 1)
+		if err != nil && err.Error() != ErrDBNotFound.Error() {
+			return nil, err
+		}
+		if prev != nil {
+			prevRoot = prev.Root
+		}
+	}
+	changesRoot := state.cache.ChangesRoot()
+	return &states.StateRoot{
+		Height:      b.Height,
+		BlockHash:   b.Hash(),
+		ChangesRoot: changesRoot,
+		Root:        blockchain.ComputeStateRoot(prevRoot, changesRoot),
+	}, nil
+}
+
+// updateStorageTrie applies the storage written by the block to the storage
+// trie of the previous block and returns the new root.
+func (c *LedgerStore) updateStorageTrie(batch database.Batch, state *blockState, height uint32) (common.Uint256, error) {
+	var prevRoot common.Uint256
+	if height > 0 {
+		prev, err := c.GetStateRoot(height - 1)
+		if err != nil && err.Error() != ErrDBNotFound.Error() {
+			return prevRoot, err
+		}
+		if prev != nil {
+			prevRoot = prev.StorageRoot
+		}
+	}
+	storageTrie := trie.New(prevRoot, c)
+	for _, write := range state.cache.SortedWrites() {
+		if write.Prefix != sb.ST_Storage {
+			continue
+		}
+		var err error
+		item, ok := write.Item.(*states.StorageItem)
+		if write.IsDeleted || !ok {
+			err = storageTrie.Delete([]byte(write.Key))
+		} else {
+			err = storageTrie.Put([]byte(write.Key), item.Value)
+		}
+		if err != nil {
+			return prevRoot, err
+		}
+	}
+	if err := storageTrie.Commit(batch); err != nil {
+		return prevRoot, err
+	}
+	return storageTrie.Hash(), nil
+}
+
+func (c *LedgerStore) GetStateRoot(height uint32) (*states.StateRoot, error) {
+	data, err := c.Get(states.StateRootKey(height))
+	if err != nil {
+		return nil, err
+	}
+	root := new(states.StateRoot)
+	if err := root.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// GetContractStateHeight returns the block which the contract state was
+// persisted with last.
+func (c *LedgerStore) GetContractStateHeight() (uint32, common.Uint256, error) {
+	var hash common.Uint256
+	data, err := c.Get(contractStateKey)
+	if err != nil {
+		return 0, hash, err
+	}
+	r := bytes.NewReader(data)
+	height, err := common.ReadUint32(r)
+	if err != nil {
+		return 0, hash, err
+	}
+	if err := hash.Deserialize(r); err != nil {
+		return 0, hash, err
+	}
+	return height, hash, nil
+}
+
+// CheckStorageTrie reports whether the storage trie is complete. A chain with
+// no block persisted yet starts with a complete trie, any other chain without
+// the flag needs a contract reindex to build it.
+func (c *LedgerStore) CheckStorageTrie() (bool, error) {
+	if _, err := c.Get([]byte(StorageTrieFlag)); err == nil {
+		return true, nil
+	} else if err.Error() != ErrDBNotFound.Error() {
+		return false, err
+	}
+	if c.GetHeight() > 0 {
+		return false, nil
+	}
+	return true, c.Put([]byte(StorageTrieFlag), []byte{1})
+}
+
+// CheckContractState verifies the contract state has been persisted with the
+// current best block.
+func (c *LedgerStore) CheckContractState() error {
+	if err := c.checkReindexFlag(); err != nil {
+		return err
+	}
+	bestHeight := c.GetHeight()
+	height, hash, err := c.GetContractStateHeight()
+	if err != nil {
+		if err.Error() != ErrDBNotFound.Error() {
+			return err
+		}
+		if bestHeight > 0 {
+			log.Warn("contract state height not found, the contract state may be inconsistent with the chain")
+		}
+		return nil
+	}
+	if height != bestHeight {
+		return fmt.Errorf("contract state height %d does not match chain height %d", height, bestHeight)
+	}
+	bestHash, err := c.GetBlockHash(bestHeight)
+	if err != nil {
+		return err
+	}
+	if !hash.IsEqual(bestHash) {
+		return errors.New("contract state block hash does not match the best block " + bestHash.String())
+	}
+	return nil
+}
@@ -12,7 +12,7 @@ import (
 
 	"github.com/elastos/Elastos.ELA.Utility/common"
 
-	"github.com/elastos/Elastos.ELA.SideChain/events"
+	sideser "github.com/elastos/Elastos.ELA.SideChain/service"
 
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
 	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
@@ -33,12 +33,18 @@ const RunTime_Log = "RunTime_Log"
 
 // Response represent the response data structure.
 type ResponseExt struct {
-	Action   string
-	Result   bool
-	Error    int
-	Desc     interface{}
-	TxID     string
-	CodeHash string
+	Action    string
+	Result    bool
+	Error     int
+	Desc      interface{}
+	TxID      string
+	// TxHash is the txid in the reversed form the RPC prints it in, TxID
+	// keeps the byte order the existing clients parse.
+	TxHash    string `json:",omitempty"`
+	CodeHash  string
+	Height    uint32 `json:",omitempty"`
+	Index     int    `json:",omitempty"`
+	EventName string `json:",omitempty"`
 }
 
 func (c *LedgerStore) PersisAccount(batch database.Batch, block *side.Block) error {
@@ -106,15 +112,21 @@ func (c *LedgerStore) PersisAccount(batch database.Batch, block *side.Block) err
 	return nil
 }
 
-func (c *LedgerStore) PersistDeployTransaction(block *side.Block, tx *side.Transaction, batch database.Batch) error {
+// PersistDeployTransaction executes a deploy transaction. A failed execution
+// is recorded as a failure receipt and is not an error, the returned error
+// means the node failed to persist the transaction.
+func (c *LedgerStore) PersistDeployTransaction(block *side.Block, tx *side.Transaction, batch database.Batch,
+	state *blockState) error {
 	payloadDeploy, ok := tx.Payload.(*types.PayloadDeploy)
 	if !ok {
 		return errors.New("invalid deploy payload")
 	}
-	dbCache := blockchain.NewDBCache(c)
+	dbCache := blockchain.NewChildDBCache(state.cache)
+	stateMachine := service.NewStateMachine(dbCache, dbCache)
+	stateMachine.Environment = service.NewExecutionEnvironment(block, tx)
 	smartcontract, err := smartcontract.NewSmartContract(&smartcontract.Context{
 		Caller:       payloadDeploy.ProgramHash,
-		StateMachine: *service.NewStateMachine(dbCache, dbCache),
+		StateMachine: *stateMachine,
 		DBCache:      batch,
 		Code:         payloadDeploy.Code.Code,
 		Time:         big.NewInt(int64(block.Timestamp)),
@@ -123,52 +135,33 @@ func (c *LedgerStore) PersistDeployTransaction(block *side.Block, tx *side.Trans
 		Trigger:      avm.Application,
 	})
 	if err != nil {
-		events.Notify(event.ETDeployTransaction, &ResponseExt{
-			Action:   DEPLOY_TRANSACTION,
-			Result:   false,
-			Desc:     err.Error(),
-			TxID:     tx.Hash().String(),
-			CodeHash: "",
-		})
-		return err
+		return c.executionFailed(batch, state, tx, DEPLOY_TRANSACTION, "", newApplicationLog(tx.Hash(), nil, nil, err))
 	}
 	ret, err := smartcontract.DeployContract(payloadDeploy)
+	engine := smartcontract.Engine.(*avm.ExecutionEngine)
 	if err != nil {
-		events.Notify(event.ETDeployTransaction, &ResponseExt{
-			Action:   DEPLOY_TRANSACTION,
-			Result:   false,
-			Desc:     err.Error(),
-			TxID:     tx.Hash().String(),
-			CodeHash: "",
-		})
-		return err
+		return c.executionFailed(batch, state, tx, DEPLOY_TRANSACTION, "",
+			newApplicationLog(tx.Hash(), engine, stateMachine, err))
 	}
 	codeHash, err := params.ToCodeHash(ret)
 	if err != nil {
-		events.Notify(event.ETDeployTransaction, &ResponseExt{
-			Action:   DEPLOY_TRANSACTION,
-			Result:   false,
-			Desc:     err.Error(),
-			TxID:     tx.Hash().String(),
-			CodeHash: codeHash.String(),
-		})
-		return err
+		return c.executionFailed(batch, state, tx, DEPLOY_TRANSACTION, "",
+			newApplicationLog(tx.Hash(), engine, stateMachine, err))
 	}
 
-	contract, err := c.GetContract(codeHash)
-	if err != nil && contract != nil {
-		events.Notify(event.ETDeployTransaction, &ResponseExt{
-			Action:   DEPLOY_TRANSACTION,
-			Result:   false,
-			Desc:     err.Error(),
-			TxID:     tx.Hash().String(),
-			CodeHash: codeHash.String(),
-		})
-		return err
-	}
 	//because neo compiler use [AppCall(hash)] ，will change hash168 to hash160,so we deploy contract use hash160
 	data := params.UInt168ToUInt160(codeHash)
 
+	// deploying a contract that exists leaves it as it is, manifest included.
+	// The deploy script has already added the contract to dbCache, the block
+	// state still tells whether it existed before this transaction.
+	existing, err := state.cache.TryGet(sb.ST_Contract, string(data))
+	if err != nil && err.Error() != ErrDBNotFound.Error() {
+		return err
+	}
+	if existing == nil && payloadDeploy.Manifest != nil {
+		state.manifests[*codeHash] = payloadDeploy.Manifest.Bytes()
+	}
 	dbCache.GetOrAdd(sb.ST_Contract, string(data), &states.ContractState{
 		Code:        payloadDeploy.Code,
 		Name:        payloadDeploy.Name,
@@ -178,50 +171,65 @@ func (c *LedgerStore) PersistDeployTransaction(block *side.Block, tx *side.Trans
 		Description: payloadDeploy.Description,
 		ProgramHash: payloadDeploy.ProgramHash,
 	})
+	dbCache.Commit()
+	err = c.persistContractChanges(block, tx, state, []*service.ContractChange{{
+		Action:   states.ContractDeployed,
+		CodeHash: *codeHash,
+		Name:     payloadDeploy.Name,
+		Author:   payloadDeploy.Author,
+	}}, gasLeft(payloadDeploy.Gas, engine))
+	if err != nil {
+		return err
+	}
+	err = c.persistApplicationLog(batch, newApplicationLog(tx.Hash(), engine, stateMachine, nil))
+	if err != nil {
+		return err
+	}
 	log.Info("deploy contract suc:", codeHash.String())
-	events.Notify(event.ETDeployTransaction, &ResponseExt{
+	state.addContractEvents(stateMachine)
+	if err := c.addNEP5Transfers(state, block, stateMachine); err != nil {
+		return err
+	}
+	if err := c.addNEP11Transfers(state, block, stateMachine); err != nil {
+		return err
+	}
+	state.notifyEvents(stateMachine)
+	state.notify(event.ETDeployTransaction, &ResponseExt{
 		Action:   DEPLOY_TRANSACTION,
 		Result:   true,
 		Desc:     "Success",
 		TxID:     tx.Hash().String(),
+		TxHash:   sideser.ToReversedString(tx.Hash()),
 		CodeHash: codeHash.String(),
 	})
-	dbCache.Commit()
 	return nil
 }
 
-func (c *LedgerStore) persisInvokeTransaction(block *side.Block, tx *side.Transaction, batch database.Batch) error {
+// persisInvokeTransaction executes an invoke transaction. A failed execution
+// is recorded as a failure receipt and is not an error, the returned error
+// means the node failed to persist the transaction.
+func (c *LedgerStore) persisInvokeTransaction(block *side.Block, tx *side.Transaction, batch database.Batch,
+	state *blockState) error {
 	payloadInvoke := tx.Payload.(*types.PayloadInvoke)
+	codeHash := payloadInvoke.CodeHash.String()
 	constractState := states.NewContractState()
+	// the contract is looked up through the block state, so a contract deployed
+	// earlier in the same block can be invoked.
+	dbCache := blockchain.NewChildDBCache(state.cache)
 	if !payloadInvoke.CodeHash.IsEqual(common.Uint168{}) {
-		contract, err := c.GetContract(&payloadInvoke.CodeHash)
-		if err != nil {
-			events.Notify(event.ETInvokeTransaction, &ResponseExt{
-				Action:   INVOKE_TRANSACTION,
-				Result:   false,
-				Desc:     err.Error(),
-				TxID:     tx.Hash().String(),
-				CodeHash: payloadInvoke.CodeHash.String(),
-			})
-			log.Errorf("invoke transaction failed, txid:%s, error:%s", tx.Hash(), err.Error())
+		item, err := dbCache.TryGet(sb.ST_Contract, string(params.UInt168ToUInt160(&payloadInvoke.CodeHash)))
+		if err != nil && err.Error() != ErrDBNotFound.Error() {
 			return err
 		}
-		state, err := states.GetStateValue(sb.ST_Contract, contract)
-		if err != nil {
-			events.Notify(event.ETInvokeTransaction, &ResponseExt{
-				Action:   INVOKE_TRANSACTION,
-				Result:   false,
-				Desc:     err.Error(),
-				TxID:     tx.Hash().String(),
-				CodeHash: payloadInvoke.CodeHash.String(),
-			})
-			log.Errorf("invoke transaction failed, txid:%s, error:%s", tx.Hash(), err.Error())
-			return err
+		contract, ok := item.(*states.ContractState)
+		if !ok || contract == nil {
+			return c.executionFailed(batch, state, tx, INVOKE_TRANSACTION, codeHash,
+				newApplicationLog(tx.Hash(), nil, nil, errors.New("unknown contract "+codeHash)))
 		}
-		constractState = state.(*states.ContractState)
+		constractState = contract
 	}
-	dbCache := blockchain.NewDBCache(c)
 	stateMachine := service.NewStateMachine(dbCache, dbCache)
+	stateMachine.Environment = service.NewExecutionEnvironment(block, tx)
 	smartcontract, err := smartcontract.NewSmartContract(&smartcontract.Context{
 		Caller:         payloadInvoke.ProgramHash,
 		StateMachine:   *stateMachine,
@@ -238,42 +246,89 @@ func (c *LedgerStore) persisInvokeTransaction(block *side.Block, tx *side.Transa
 		Trigger:        avm.Application,
 	})
 	if err != nil {
-		events.Notify(event.ETInvokeTransaction, &ResponseExt{
-			Action:   INVOKE_TRANSACTION,
-			Result:   false,
-			Desc:     err.Error(),
-			TxID:     tx.Hash().String(),
-			CodeHash: payloadInvoke.CodeHash.String(),
-		})
-		log.Errorf("invoke transaction failed, txid:%s, error:%s", tx.Hash(), err.Error())
+		return c.executionFailed(batch, state, tx, INVOKE_TRANSACTION, codeHash, newApplicationLog(tx.Hash(), nil, nil, err))
 	}
 
-	ret, err := smartcontract.InvokeContract()
+	engine := smartcontract.Engine.(*avm.ExecutionEngine)
+	err = smartcontract.Execute()
+	appLog := newApplicationLog(tx.Hash(), engine, stateMachine, err)
 	if err != nil {
-		events.Notify(event.ETInvokeTransaction, &ResponseExt{
-			Action:   INVOKE_TRANSACTION,
-			Result:   false,
-			Desc:     err.Error(),
-			TxID:     tx.Hash().String(),
-			CodeHash: payloadInvoke.CodeHash.String(),
-		})
-		log.Errorf("invoke transaction failed, txid:%s, error:%s", tx.Hash(), err.Error())
-		return err
+		return c.executionFailed(batch, state, tx, INVOKE_TRANSACTION, codeHash, appLog)
+	}
+	ret, err := smartcontract.InvokeResult()
+	if err != nil {
+		return c.executionFailed(batch, state, tx, INVOKE_TRANSACTION, codeHash,
+			newApplicationLog(tx.Hash(), engine, stateMachine, err))
 	}
 	log.Info("InvokeContract ret=", ret)
 	stateMachine.CloneCache.Commit()
 	dbCache.Commit()
-	events.Notify(event.ETInvokeTransaction, &ResponseExt{
+	err = c.persistContractChanges(block, tx, state, stateMachine.ContractChanges,
+		gasLeft(payloadInvoke.Gas, engine))
+	if err != nil {
+		return err
+	}
+	err = c.persistApplicationLog(batch, appLog)
+	if err != nil {
+		return err
+	}
+	state.addContractEvents(stateMachine)
+	if err := c.addNEP5Transfers(state, block, stateMachine); err != nil {
+		return err
+	}
+	if err := c.addNEP11Transfers(state, block, stateMachine); err != nil {
+		return err
+	}
+	state.notifyEvents(stateMachine)
+	state.notify(event.ETInvokeTransaction, &ResponseExt{
 		Action:   INVOKE_TRANSACTION,
 		Result:   true,
 		Desc:     ret,
 		TxID:     tx.Hash().String(),
-		CodeHash: payloadInvoke.CodeHash.String(),
+		TxHash:   sideser.ToReversedString(tx.Hash()),
+		CodeHash: codeHash,
 	})
 
 	return nil
 }
 
+// gasLeft returns the gas an execution created with gas has not consumed.
+func gasLeft(gas common.Fixed64, engine *avm.ExecutionEngine) common.Fixed64 {
+	return gas + avm.FreeGas - common.Fixed64(engine.GetGasConsumed())
+}
+
+// executionFailed persists the failure receipt of a transaction whose
+// execution failed. The failure is decided by the transaction and the chain
+// state only, so the block stays valid and the contract state is unchanged.
+func (c *LedgerStore) executionFailed(batch database.Batch, state *blockState, tx *side.Transaction, action string,
+	codeHash string, appLog *states.ApplicationLog) error {
+	log.Warnf("%s failed, txid:%s, error:%s", action, tx.Hash(), appLog.Fault)
+	eventType := event.ETInvokeTransaction
+	if action == DEPLOY_TRANSACTION {
+		eventType = event.ETDeployTransaction
+	}
+	state.notify(eventType, &ResponseExt{
+		Action:   action,
+		Result:   false,
+		Desc:     appLog.Fault,
+		TxID:     tx.Hash().String(),
+		TxHash:   sideser.ToReversedString(tx.Hash()),
+		CodeHash: codeHash,
+	})
+	return c.persistApplicationLog(batch, appLog)
+}
+
+// notifyEvents publishes the notifications and logs of a persisted execution,
+// those of a failed execution are only recorded in its receipt.
+func (state *blockState) notifyEvents(stateMachine *service.StateMachine) {
+	for _, args := range stateMachine.NotifyEvents {
+		state.notify(event.ETRunTimeNotify, args)
+	}
+	for _, item := range stateMachine.LogItems {
+		state.notify(event.ETRunTimeLog, item)
+	}
+}
+
 func (c *LedgerStore) GetContract(codeHash *common.Uint168) ([]byte, error) {
 	prefix := []byte{byte(sb.ST_Contract)}
 
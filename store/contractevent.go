@@ -0,0 +1,40 @@
+package store
+
+import (
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+// addContractEvents records the notifications of a persisted execution to be
+// indexed with the block.
+func (state *blockState) addContractEvents(stateMachine *service.StateMachine) {
+	for i, args := range stateMachine.NotifyEvents {
+		state.events = append(state.events, &states.ContractEvent{
+			CodeHash:  args.ScriptHash,
+			Height:    args.Height,
+			TxID:      args.TxID,
+			Index:     uint32(args.Index),
+			EventName: args.EventName,
+			State:     stateMachine.Notifications[i].State,
+		})
+	}
+}
+
+// persistContractEvents writes the event index and the event bloom of a
+// block, blocks without events have no bloom.
+func persistContractEvents(batch database.Batch, state *blockState, height uint32) error {
+	if len(state.events) == 0 {
+		return nil
+	}
+	var bloom states.EventBloom
+	for seq, event := range state.events {
+		bloom.AddEvent(&event.CodeHash, event.EventName)
+		key := states.ContractEventKey(height, &event.CodeHash, uint32(seq))
+		if err := batch.Put(key, event.Bytes()); err != nil {
+			return err
+		}
+	}
+	return batch.Put(states.EventBloomKey(height), bloom[:])
+}
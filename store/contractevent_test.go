@@ -0,0 +1,65 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+// emitNotify emits a notification of the event name with a value.
+func emitNotify(builder *avm.ParamsBuilder, name string, value []byte) {
+	builder.EmitPushByteArray(value)
+	builder.EmitPushByteArray([]byte(name))
+	builder.EmitPushInteger(2)
+	builder.Emit(avm.PACK)
+	builder.EmitSysCall("Neo.Runtime.Notify")
+}
+
+func TestPersistContractEvents(t *testing.T) {
+	c, db := newTestLedgerStore()
+	builder := scriptBuilder()
+	emitNotify(builder, "transfer", []byte("a"))
+	emitNotify(builder, "approve", []byte("b"))
+	builder.Emit(avm.RET)
+	deploy, codeHash := deployTx(builder.Bytes())
+	_, err := persistBlock(c, 1, deploy)
+	assert.NoError(t, err)
+	_, ok := db.data[string(states.EventBloomKey(1))]
+	assert.False(t, ok)
+
+	before := db.snapshot()
+	invoke := invokeTx(codeHash, 1, "")
+	block, err := persistBlock(c, 2, invoke)
+	assert.NoError(t, err)
+	var bloom states.EventBloom
+	copy(bloom[:], db.data[string(states.EventBloomKey(2))])
+	assert.True(t, bloom.Test(codeHash, "transfer"))
+	assert.True(t, bloom.Test(codeHash, "approve"))
+	for seq, name := range []string{"transfer", "approve"} {
+		data, ok := db.data[string(states.ContractEventKey(2, codeHash, uint32(seq)))]
+		assert.True(t, ok)
+		event := new(states.ContractEvent)
+		assert.NoError(t, event.Deserialize(bytes.NewReader(data)))
+		assert.Equal(t, name, event.EventName)
+		assert.Equal(t, invoke.Hash(), event.TxID)
+		assert.Equal(t, uint32(2), event.Height)
+	}
+
+	// a rollback removes the events and the bloom of the block
+	assert.NoError(t, rollbackBlock(c, block))
+	assert.Equal(t, before, db.snapshot())
+
+	// the events of a faulted execution are not indexed
+	builder = scriptBuilder()
+	emitNotify(builder, "transfer", []byte("c"))
+	builder.Emit(avm.THROW)
+	deploy, codeHash = deployTx(builder.Bytes())
+	_, err = persistBlock(c, 2, deploy, invokeTx(codeHash, 1, ""))
+	assert.NoError(t, err)
+	_, ok = db.data[string(states.EventBloomKey(2))]
+	assert.False(t, ok)
+}
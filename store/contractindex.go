@@ -0,0 +1,134 @@
+package store
+
+import (
+	"bytes"
+
+	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+func contractIndexKey(codeHash *common.Uint168) []byte {
+	return append([]byte{byte(states.IX_Contract)}, codeHash.Bytes()...)
+}
+
+func (c *LedgerStore) GetContractIndex(codeHash *common.Uint168) (*states.ContractIndex, error) {
+	data, err := c.Get(contractIndexKey(codeHash))
+	if err != nil {
+		return nil, err
+	}
+	index := new(states.ContractIndex)
+	if err := index.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// persistContractChanges updates the contract index and the manifests with
+// the contracts created, migrated and destroyed by a persisted transaction,
+// and publishes the changes once the block is committed. The transaction has
+// committed its execution to the block state, the standards of the contracts
+// it creates are detected against it on the gas the transaction left.
+func (c *LedgerStore) persistContractChanges(block *side.Block, tx *side.Transaction, state *blockState,
+	changes []*service.ContractChange, gas common.Fixed64) error {
+	txHash := tx.Hash()
+	height := block.Height
+	indexes := state.indexes
+	getIndex := func(codeHash common.Uint168) (*states.ContractIndex, error) {
+		if index, ok := indexes[codeHash]; ok {
+			return index, nil
+		}
+		index, err := c.GetContractIndex(&codeHash)
+		if err != nil && err.Error() != ErrDBNotFound.Error() {
+			return nil, err
+		}
+		indexes[codeHash] = index
+		return index, nil
+	}
+	deploy := func(codeHash common.Uint168, change *service.ContractChange) error {
+		index, err := getIndex(codeHash)
+		if err != nil {
+			return err
+		}
+		if index != nil && index.Status == states.ContractDeployed {
+			return nil
+		}
+		code, err := c.getContractCode(state, codeHash)
+		if err != nil {
+			return err
+		}
+		change.Standards = c.detectStandards(block, tx, state.cache, &codeHash, code, &gas)
+		index = &states.ContractIndex{
+			CodeHash:  codeHash,
+			DeployTx:  txHash,
+			Height:    height,
+			Author:    change.Author,
+			Name:      change.Name,
+			Status:    states.ContractDeployed,
+			Standards: change.Standards,
+		}
+		index.StateVersion = states.ContractIndexVersion
+		indexes[codeHash] = index
+		return nil
+	}
+	update := func(codeHash common.Uint168, status states.ContractStatus) (*states.ContractIndex, error) {
+		index, err := getIndex(codeHash)
+		if err != nil || index == nil {
+			return nil, err
+		}
+		index.Status = status
+		index.UpdateTx = txHash
+		index.UpdateHeight = height
+		return index, nil
+	}
+
+	for _, change := range changes {
+		state.notify(event.ETContractChanged, change)
+		switch change.Action {
+		case states.ContractDeployed:
+			if err := deploy(change.CodeHash, change); err != nil {
+				return err
+			}
+		case states.ContractMigrated:
+			if err := deploy(change.NewCodeHash, change); err != nil {
+				return err
+			}
+			index, err := update(change.CodeHash, states.ContractMigrated)
+			if err != nil {
+				return err
+			}
+			if index != nil {
+				index.MigratedTo = change.NewCodeHash
+			}
+			if err := c.migrateContractManifest(state, change.CodeHash, change.NewCodeHash); err != nil {
+				return err
+			}
+		case states.ContractDestroyed:
+			if _, err := update(change.CodeHash, states.ContractDestroyed); err != nil {
+				return err
+			}
+			state.manifests[change.CodeHash] = nil
+		}
+	}
+	return nil
+}
+
+// getContractCode returns the code of a contract in the block state, nil if
+// the contract does not exist.
+func (c *LedgerStore) getContractCode(state *blockState, codeHash common.Uint168) ([]byte, error) {
+	item, err := state.cache.TryGet(sb.ST_Contract, string(params.UInt168ToUInt160(&codeHash)))
+	if err != nil && err.Error() != ErrDBNotFound.Error() {
+		return nil, err
+	}
+	contract, ok := item.(*states.ContractState)
+	if !ok || contract == nil || contract.Code == nil {
+		return nil, nil
+	}
+	return contract.Code.Code, nil
+}
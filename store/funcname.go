@@ -3,5 +3,6 @@ package store
 import "github.com/elastos/Elastos.ELA.SideChain/blockchain"
 
 const (
-	PersisAccount blockchain.StoreFuncName = "PersisAccount"
+	PersisAccount         blockchain.StoreFuncName = "PersisAccount"
+	RollbackContractState blockchain.StoreFuncName = "RollbackContractState"
 )
\ No newline at end of file
@@ -6,8 +6,11 @@ import (
 	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
 	side "github.com/elastos/Elastos.ELA.SideChain/types"
 	"github.com/elastos/Elastos.ELA.SideChain/database"
+	"github.com/elastos/Elastos.ELA.SideChain/events"
 
 	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/event"
 )
 
 var (
@@ -19,11 +22,13 @@ type LedgerStore struct {
 }
 
 func NewLedgerStore(store *sb.ChainStore) (*LedgerStore, error) {
+	store.Database = &eventDB{Database: store.Database}
 	ledger := &LedgerStore{
 		ChainStore: store,
 	}
 	ledger.RegisterFunctions(true, sb.StoreFuncNames.PersistTransactions, ledger.persistTransactions)
 	ledger.RegisterFunctions(true, PersisAccount, ledger.PersisAccount)
+	ledger.RegisterFunctions(false, RollbackContractState, ledger.rollbackContractState)
 
 	return ledger, nil
 }
@@ -49,29 +54,95 @@ func (c *LedgerStore) persistTransactions(batch database.Batch, b *side.Block) e
 			}
 			c.PersistMainchainTx(batch, *hash)
 		}
+	}
+	if err := c.persistContracts(batch, b); err != nil {
+		return err
+	}
+	notifyOnCommit(batch, event.ETBlockPersisted, b)
+	return nil
+}
 
-		if txn.TxType == side.Deploy {
-			err := c.PersistDeployTransaction(b, txn, batch)
-			if err != nil {
-				log.Error(err.Error())
-				//return err will effect manual mining
-				return nil
-			}
-
+// persistContracts executes the deploy and invoke transactions of a block and
+// writes the contract state they change together with its undo journal.
+func (c *LedgerStore) persistContracts(batch database.Batch, b *side.Block) error {
+	state := c.newBlockState()
+	journal := newJournalBatch(batch, c)
+	for _, txn := range b.Transactions {
+		var err error
+		switch txn.TxType {
+		case side.Deploy:
+			err = c.PersistDeployTransaction(b, txn, journal, state)
+		case side.Invoke:
+			err = c.persisInvokeTransaction(b, txn, journal, state)
 		}
-
-		if txn.TxType == side.Invoke {
-			err := c.persisInvokeTransaction(b, txn, batch)
-			if err != nil {
-				log.Error(err.Error())
-				//return err will effect manual mining
-				return nil
-			}
+		// failed executions are persisted as receipts, an error here means
+		// the node itself failed and must not go on with the block.
+		if err != nil {
+			log.Errorf("persist contract transaction failed, block:%d, txid:%s, error:%s",
+				b.Height, txn.Hash(), err.Error())
+			return err
 		}
 	}
+	if err := c.commitBlockState(journal, state, b); err != nil {
+		return err
+	}
+	if err := journal.commit(b); err != nil {
+		return err
+	}
+	for _, e := range state.published {
+		notifyOnCommit(batch, e.eventType, e.data)
+	}
 	return nil
 }
 
+type pendingEvent struct {
+	eventType events.EventType
+	data      interface{}
+}
+
+// eventDB creates the batches the blocks are persisted with, the events of a
+// block are published once its batch is committed so the subscribers never see
+// a block that is not persisted.
+type eventDB struct {
+	database.Database
+}
+
+func (db *eventDB) NewBatch() database.Batch {
+	return &eventBatch{Batch: db.Database.NewBatch()}
+}
+
+type eventBatch struct {
+	database.Batch
+	pending []*pendingEvent
+}
+
+func (b *eventBatch) Commit() error {
+	if err := b.Batch.Commit(); err != nil {
+		return err
+	}
+	pending := b.pending
+	b.pending = nil
+	for _, e := range pending {
+		events.Notify(e.eventType, e.data)
+	}
+	return nil
+}
+
+func (b *eventBatch) Rollback() error {
+	b.pending = nil
+	return b.Batch.Rollback()
+}
+
+// notifyOnCommit publishes the event once batch is committed, the event is
+// published at once if the batch is not created by an eventDB.
+func notifyOnCommit(batch database.Batch, eventType events.EventType, data interface{}) {
+	if b, ok := batch.(*eventBatch); ok {
+		b.pending = append(b.pending, &pendingEvent{eventType: eventType, data: data})
+		return
+	}
+	events.Notify(eventType, data)
+}
+
 func (c *LedgerStore) GetUnspents(txid common.Uint256) ([]*side.Output, error) {
 	if ok, _ := c.ContainsUnspent(txid, 0); ok {
 		tx, _, err := c.GetTransaction(txid)
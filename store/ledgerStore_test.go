@@ -0,0 +1,283 @@
+package store
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+// memDB is an in memory database, Get fails with err when it is set.
+type memDB struct {
+	database.Database
+	data map[string][]byte
+	err  error
+}
+
+func newMemDB() *memDB {
+	return &memDB{data: make(map[string][]byte)}
+}
+
+func (db *memDB) Get(key []byte) ([]byte, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+	if value, ok := db.data[string(key)]; ok {
+		return value, nil
+	}
+	return nil, ErrDBNotFound
+}
+
+func (db *memDB) Put(key []byte, value []byte) error {
+	db.data[string(key)] = value
+	return nil
+}
+
+func (db *memDB) Delete(key []byte) error {
+	delete(db.data, string(key))
+	return nil
+}
+
+func (db *memDB) NewBatch() database.Batch {
+	return &memDBBatch{db: db}
+}
+
+func (db *memDB) NewIterator(prefix []byte) database.Iterator {
+	iter := &memIterator{pos: -1}
+	for key := range db.data {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			iter.keys = append(iter.keys, key)
+		}
+	}
+	sort.Strings(iter.keys)
+	for _, key := range iter.keys {
+		iter.values = append(iter.values, db.data[key])
+	}
+	return iter
+}
+
+// memDBBatch writes to the database only when it is committed.
+type memDBBatch struct {
+	database.Batch
+	db     *memDB
+	writes []func()
+}
+
+func (b *memDBBatch) Put(key []byte, value []byte) error {
+	k, v := string(key), append([]byte{}, value...)
+	b.writes = append(b.writes, func() { b.db.data[k] = v })
+	return nil
+}
+
+func (b *memDBBatch) Delete(key []byte) error {
+	k := string(key)
+	b.writes = append(b.writes, func() { delete(b.db.data, k) })
+	return nil
+}
+
+func (b *memDBBatch) Commit() error {
+	for _, write := range b.writes {
+		write()
+	}
+	b.writes = nil
+	return nil
+}
+
+func (b *memDBBatch) Rollback() error {
+	b.writes = nil
+	return nil
+}
+
+type memIterator struct {
+	database.Iterator
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func (iter *memIterator) Next() bool {
+	if iter.pos < len(iter.keys) {
+		iter.pos++
+	}
+	return iter.pos < len(iter.keys)
+}
+
+func (iter *memIterator) Prev() bool {
+	if iter.pos >= 0 {
+		iter.pos--
+	}
+	return iter.pos >= 0
+}
+
+func (iter *memIterator) First() bool {
+	iter.pos = 0
+	return len(iter.keys) > 0
+}
+
+func (iter *memIterator) Last() bool {
+	iter.pos = len(iter.keys) - 1
+	return len(iter.keys) > 0
+}
+
+func (iter *memIterator) Seek(key []byte) bool {
+	iter.pos = sort.SearchStrings(iter.keys, string(key))
+	return iter.pos < len(iter.keys)
+}
+
+func (iter *memIterator) Key() []byte {
+	if iter.pos < 0 || iter.pos >= len(iter.keys) {
+		return nil
+	}
+	return []byte(iter.keys[iter.pos])
+}
+
+func (iter *memIterator) Value() []byte {
+	if iter.pos < 0 || iter.pos >= len(iter.keys) {
+		return nil
+	}
+	return iter.values[iter.pos]
+}
+
+func (iter *memIterator) Release() {}
+
+func newTestLedgerStore() (*LedgerStore, *memDB) {
+	db := newMemDB()
+	return &LedgerStore{ChainStore: &sb.ChainStore{Database: &eventDB{Database: db}}}, db
+}
+
+// persistBlock persists the contract state of a block at height the way the
+// chain store does, the batch is dropped if persisting fails.
+func persistBlock(c *LedgerStore, height uint32, txs ...*side.Transaction) (*side.Block, error) {
+	block := &side.Block{
+		Header:       side.Header{Height: height, Timestamp: 1500000000 + height},
+		Transactions: txs,
+	}
+	batch := c.NewBatch()
+	if err := c.persistContracts(batch, block); err != nil {
+		batch.Rollback()
+		return block, err
+	}
+	return block, batch.Commit()
+}
+
+func rollbackBlock(c *LedgerStore, block *side.Block) error {
+	batch := c.NewBatch()
+	if err := c.rollbackContractState(batch, block); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+func scriptBuilder() *avm.ParamsBuilder {
+	return avm.NewParamsBuider(new(bytes.Buffer))
+}
+
+// emitStoragePut emits a put of value at key into the storage of the
+// executing contract.
+func emitStoragePut(builder *avm.ParamsBuilder, key, value string) {
+	builder.EmitPushByteArray([]byte(value))
+	builder.EmitPushByteArray([]byte(key))
+	builder.EmitSysCall("Neo.Storage.GetContext")
+	builder.EmitSysCall("Neo.Storage.Put")
+}
+
+// deployTx deploys code with the gas Neo.Contract.Create costs.
+func deployTx(code []byte) (*side.Transaction, *common.Uint168) {
+	codeHash, _ := params.ToCodeHash(code)
+	return &side.Transaction{
+		TxType: side.Deploy,
+		Payload: &types.PayloadDeploy{
+			Code: &types.FunctionCode{Code: code, ReturnType: contract.Void},
+			Name: "test",
+			Gas:  500 * 100000000,
+		},
+	}, codeHash
+}
+
+// invokeTx calls the contract with operation, nonce tells apart the
+// transactions making the same call.
+func invokeTx(codeHash *common.Uint168, nonce byte, operation string) *side.Transaction {
+	builder := scriptBuilder()
+	if operation != "" {
+		builder.EmitPushByteArray([]byte(operation))
+	}
+	builder.EmitPushCall(common.BytesReverse(params.UInt168ToUInt160(codeHash)))
+	return &side.Transaction{
+		TxType: side.Invoke,
+		Payload: &types.PayloadInvoke{
+			CodeHash:    *codeHash,
+			Code:        builder.Bytes(),
+			ProgramHash: common.Uint168{0x21, nonce},
+		},
+	}
+}
+
+// operationScript runs body and returns if the operation the contract is
+// called with is op, and goes on after it otherwise.
+func operationScript(op string, body []byte) []byte {
+	builder := scriptBuilder()
+	builder.Emit(avm.DUP)
+	builder.EmitPushByteArray([]byte(op))
+	builder.Emit(avm.EQUAL)
+	builder.Emit(avm.JMPIFNOT)
+	body = append(body, byte(avm.RET))
+	offset := len(body) + 3
+	return append(append(builder.Bytes(), byte(offset), byte(offset>>8)), body...)
+}
+
+func contractScript(operations ...[]byte) []byte {
+	var code []byte
+	for _, operation := range operations {
+		code = append(code, operation...)
+	}
+	return append(code, byte(avm.RET))
+}
+
+func (db *memDB) snapshot() map[string][]byte {
+	data := make(map[string][]byte, len(db.data))
+	for k, v := range db.data {
+		data[k] = v
+	}
+	return data
+}
+
+func TestPersistFaultedInvoke(t *testing.T) {
+	c, db := newTestLedgerStore()
+	builder := scriptBuilder()
+	emitStoragePut(builder, "key", "value")
+	builder.Emit(avm.THROW)
+	deploy, codeHash := deployTx(builder.Bytes())
+	_, err := persistBlock(c, 1, deploy)
+	assert.NoError(t, err)
+
+	invoke := invokeTx(codeHash, 1, "")
+	_, err = persistBlock(c, 2, invoke)
+	assert.NoError(t, err)
+	_, ok := db.data[string(storageKey(*codeHash, "key"))]
+	assert.False(t, ok)
+	appLog, err := c.GetApplicationLog(invoke.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, byte(avm.FAULT), appLog.VMState&byte(avm.FAULT))
+
+	// the same put is persisted when the contract halts
+	builder = scriptBuilder()
+	emitStoragePut(builder, "key", "value")
+	builder.Emit(avm.RET)
+	deploy, codeHash = deployTx(builder.Bytes())
+	_, err = persistBlock(c, 3, deploy, invokeTx(codeHash, 1, ""))
+	assert.NoError(t, err)
+	_, ok = db.data[string(storageKey(*codeHash, "key"))]
+	assert.True(t, ok)
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+// getContractManifest returns the manifest of a contract as the block being
+// persisted leaves it, nil if the contract has none.
+func (c *LedgerStore) getContractManifest(state *blockState, codeHash common.Uint168) ([]byte, error) {
+	if manifest, ok := state.manifests[codeHash]; ok {
+		return manifest, nil
+	}
+	manifest, err := c.Get(states.ContractManifestKey(&codeHash))
+	if err != nil && err.Error() != ErrDBNotFound.Error() {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// migrateContractManifest moves the manifest of a migrated contract to the
+// contract it is migrated to, unless that one has a manifest of its own.
+func (c *LedgerStore) migrateContractManifest(state *blockState, from, to common.Uint168) error {
+	manifest, err := c.getContractManifest(state, from)
+	if err != nil || manifest == nil {
+		return err
+	}
+	existing, err := c.getContractManifest(state, to)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		state.manifests[to] = manifest
+	}
+	state.manifests[from] = nil
+	return nil
+}
+
+func persistContractManifests(batch database.Batch, state *blockState) error {
+	for codeHash, manifest := range state.manifests {
+		hash := codeHash
+		if manifest == nil {
+			if err := batch.Delete(states.ContractManifestKey(&hash)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := batch.Put(states.ContractManifestKey(&hash), manifest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
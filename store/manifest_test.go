@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+func TestPersistContractManifests(t *testing.T) {
+	builder := scriptBuilder()
+	builder.EmitSysCall("Neo.Contract.Destroy")
+	codeB := contractScript(operationScript("destroy", builder.Bytes()))
+	codeA := contractScript(operationScript("migrate", migrateScript(codeB)))
+	contractB, _ := params.ToCodeHash(codeB)
+	deployWith := func(method string) *side.Transaction {
+		tx, _ := deployTx(codeA)
+		tx.PayloadVersion = types.DeployManifestPayloadVersion
+		tx.Payload.(*types.PayloadDeploy).Manifest = &types.ContractManifest{
+			Methods: []types.ManifestMethod{{Name: method, ReturnType: contract.Void}},
+		}
+		return tx
+	}
+	_, contractA := deployTx(codeA)
+	deploy := deployWith("migrate")
+	manifest := deploy.Payload.(*types.PayloadDeploy).Manifest.Bytes()
+
+	c, db := newTestLedgerStore()
+	getManifest := func(codeHash *common.Uint168) []byte {
+		return db.data[string(states.ContractManifestKey(codeHash))]
+	}
+	txs := [][]*side.Transaction{
+		{deploy},
+		// deploying the contract again does not replace its manifest
+		{deployWith("other")},
+		{invokeTx(contractA, 1, "migrate")},
+		{invokeTx(contractB, 2, "destroy")},
+	}
+	var snapshots []map[string][]byte
+	var blocks []*side.Block
+	for i, blockTxs := range txs {
+		snapshots = append(snapshots, db.snapshot())
+		block, err := persistBlock(c, uint32(i+1), blockTxs...)
+		assert.NoError(t, err)
+		blocks = append(blocks, block)
+
+		switch i {
+		case 0, 1:
+			assert.Equal(t, manifest, getManifest(contractA))
+		case 2:
+			assert.Nil(t, getManifest(contractA))
+			assert.Equal(t, manifest, getManifest(contractB))
+		case 3:
+			assert.Nil(t, getManifest(contractB))
+		}
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		assert.NoError(t, rollbackBlock(c, blocks[i]))
+		assert.Equal(t, snapshots[i], db.data)
+	}
+}
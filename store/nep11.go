@@ -0,0 +1,191 @@
+package store
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+// nep11Balance is the balance of an account in a NEP-11 token changed by the
+// block, it is written under the account and under the token.
+type nep11Balance struct {
+	address   []byte
+	assetHash common.Uint168
+	tokenID   []byte
+	balance   *states.NEP5Balance
+}
+
+func (c *LedgerStore) GetNEP11Balance(address []byte, assetHash *common.Uint168, tokenID []byte) (*states.NEP5Balance, error) {
+	data, err := c.Get(states.NEP11BalanceKey(address, assetHash, tokenID))
+	if err != nil {
+		return nil, err
+	}
+	balance := new(states.NEP5Balance)
+	if err := balance.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// nep11Transfer returns the transfer raised by a notification, it returns nil
+// if the notification is not a NEP-11 transfer. A transfer is an array of the
+// "transfer" name, the from and the to accounts, a non-negative amount and
+// the token id.
+func nep11Transfer(args *service.NotifyEventArgs) *states.NEP11Transfer {
+	if args.EventName != "transfer" {
+		return nil
+	}
+	items := args.Item.GetArray()
+	if len(items) != 5 {
+		return nil
+	}
+	from, ok := transferAccount(items[1])
+	if !ok {
+		return nil
+	}
+	to, ok := transferAccount(items[2])
+	if !ok || len(from) == 0 && len(to) == 0 {
+		return nil
+	}
+	amount, ok := transferAmount(items[3])
+	if !ok {
+		return nil
+	}
+	if _, ok := items[4].(*datatype.ByteArray); !ok {
+		return nil
+	}
+	tokenID := items[4].GetByteArray()
+	if len(tokenID) == 0 || len(tokenID) > states.MaxNEP11TokenIDSize {
+		return nil
+	}
+	return &states.NEP11Transfer{
+		NEP5Transfer: states.NEP5Transfer{
+			AssetHash: args.ScriptHash,
+			From:      from,
+			To:        to,
+			Amount:    amount,
+			TxID:      args.TxID,
+			Height:    args.Height,
+			Index:     uint32(args.Index),
+		},
+		TokenID: tokenID,
+	}
+}
+
+// addNEP11Transfers records the NEP-11 transfers raised by a persisted
+// execution and applies them to the ownership of the tokens.
+func (c *LedgerStore) addNEP11Transfers(state *blockState, block *side.Block, stateMachine *service.StateMachine) error {
+	getBalance := func(address []byte, assetHash *common.Uint168, tokenID []byte) (*nep11Balance, error) {
+		key := string(states.NEP11BalanceKey(address, assetHash, tokenID))
+		if balance, ok := state.nep11Balances[key]; ok {
+			return balance, nil
+		}
+		balance, err := c.GetNEP11Balance(address, assetHash, tokenID)
+		if err != nil {
+			if err.Error() != ErrDBNotFound.Error() {
+				return nil, err
+			}
+			balance = &states.NEP5Balance{Balance: new(big.Int)}
+		}
+		state.nep11Balances[key] = &nep11Balance{
+			address:   address,
+			assetHash: *assetHash,
+			tokenID:   tokenID,
+			balance:   balance,
+		}
+		return state.nep11Balances[key], nil
+	}
+	update := func(account []byte, transfer *states.NEP11Transfer, amount *big.Int) error {
+		if len(account) == 0 {
+			return nil
+		}
+		balance, err := getBalance(states.NEP5Address(account), &transfer.AssetHash, transfer.TokenID)
+		if err != nil {
+			return err
+		}
+		balance.balance.Balance = new(big.Int).Add(balance.balance.Balance, amount)
+		balance.balance.LastUpdatedHeight = block.Height
+		return nil
+	}
+
+	for _, args := range stateMachine.NotifyEvents {
+		transfer := nep11Transfer(args)
+		if transfer == nil {
+			continue
+		}
+		transfer.Timestamp = block.Timestamp
+		if err := update(transfer.From, transfer, new(big.Int).Neg(transfer.Amount)); err != nil {
+			return err
+		}
+		if err := update(transfer.To, transfer, transfer.Amount); err != nil {
+			return err
+		}
+		state.nep11Transfers = append(state.nep11Transfers, transfer)
+	}
+	return nil
+}
+
+// persistNEP11Transfers writes the NEP-11 ownership changed by a block and
+// the NEP-11 transfers of the block under both of their accounts. Only the
+// accounts with a positive balance of a token own it.
+func persistNEP11Transfers(batch database.Batch, state *blockState, height uint32) error {
+	keys := make([]string, 0, len(state.nep11Balances))
+	for key := range state.nep11Balances {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		balance := state.nep11Balances[key]
+		ownerKey := states.NEP11OwnerKey(&balance.assetHash, balance.tokenID, balance.address)
+		if balance.balance.Balance.Sign() == 0 {
+			if err := batch.Delete([]byte(key)); err != nil {
+				return err
+			}
+			if err := batch.Delete(ownerKey); err != nil {
+				return err
+			}
+			continue
+		}
+		data := balance.balance.Bytes()
+		if err := batch.Put([]byte(key), data); err != nil {
+			return err
+		}
+		// a negative balance, left by a transfer from an account the index
+		// never saw receive the token, does not own it.
+		if balance.balance.Balance.Sign() < 0 {
+			if err := batch.Delete(ownerKey); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := batch.Put(ownerKey, data); err != nil {
+			return err
+		}
+	}
+	for seq, transfer := range state.nep11Transfers {
+		from := states.NEP5Address(transfer.From)
+		to := states.NEP5Address(transfer.To)
+		if from != nil {
+			key := states.NEP11TransferKey(from, height, uint32(seq))
+			if err := batch.Put(key, transfer.Bytes()); err != nil {
+				return err
+			}
+		}
+		if to != nil && !bytes.Equal(from, to) {
+			key := states.NEP11TransferKey(to, height, uint32(seq))
+			if err := batch.Put(key, transfer.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
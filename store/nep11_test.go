@@ -0,0 +1,66 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+func nep11TransferScript(from, to []byte, amount int64, tokenID string) []byte {
+	builder := scriptBuilder()
+	builder.EmitPushByteArray([]byte(tokenID))
+	builder.EmitPushInteger(amount)
+	builder.EmitPushByteArray(to)
+	builder.EmitPushByteArray(from)
+	builder.EmitPushByteArray([]byte("transfer"))
+	builder.EmitPushInteger(5)
+	builder.Emit(avm.PACK)
+	builder.EmitSysCall("Neo.Runtime.Notify")
+	return builder.Bytes()
+}
+
+func TestPersistNEP11Transfers(t *testing.T) {
+	alice := bytes.Repeat([]byte{1}, states.NEP5AddressSize)
+	bob := bytes.Repeat([]byte{2}, states.NEP5AddressSize)
+	carol := bytes.Repeat([]byte{3}, states.NEP5AddressSize)
+	c, db := newTestLedgerStore()
+	deploy, codeHash := deployTx(contractScript(
+		operationScript("mint", nep11TransferScript(nil, alice, 1, "token")),
+		operationScript("transfer", nep11TransferScript(alice, bob, 1, "token")),
+		// carol never received the token
+		operationScript("steal", nep11TransferScript(carol, bob, 1, "other")),
+	))
+	_, err := persistBlock(c, 1, deploy)
+	assert.NoError(t, err)
+	owns := func(address []byte, tokenID string) bool {
+		_, ok := db.data[string(states.NEP11OwnerKey(codeHash, []byte(tokenID), address))]
+		return ok
+	}
+
+	_, err = persistBlock(c, 2, invokeTx(codeHash, 1, "mint"))
+	assert.NoError(t, err)
+	assert.True(t, owns(alice, "token"))
+
+	before := db.snapshot()
+	block, err := persistBlock(c, 3, invokeTx(codeHash, 1, "transfer"), invokeTx(codeHash, 1, "steal"))
+	assert.NoError(t, err)
+	assert.False(t, owns(alice, "token"))
+	assert.True(t, owns(bob, "token"))
+	_, err = c.GetNEP11Balance(alice, codeHash, []byte("token"))
+	assert.Error(t, err)
+	assert.True(t, owns(bob, "other"))
+	assert.False(t, owns(carol, "other"))
+	balance, err := c.GetNEP11Balance(carol, codeHash, []byte("other"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), balance.Balance.Int64())
+
+	// a rollback gives the token back to its owner before the block
+	assert.NoError(t, rollbackBlock(c, block))
+	assert.Equal(t, before, db.snapshot())
+	assert.True(t, owns(alice, "token"))
+	assert.False(t, owns(bob, "token"))
+}
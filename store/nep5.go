@@ -0,0 +1,174 @@
+package store
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+// maxNEP5AmountSize is the max size of a transfer amount raised as a byte
+// array, larger amounts are not NEP-5 transfers.
+const maxNEP5AmountSize = 32
+
+func (c *LedgerStore) GetNEP5Balance(address []byte, assetHash *common.Uint168) (*states.NEP5Balance, error) {
+	data, err := c.Get(states.NEP5BalanceKey(address, assetHash))
+	if err != nil {
+		return nil, err
+	}
+	balance := new(states.NEP5Balance)
+	if err := balance.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// transferAccount returns the account of a transfer notification, an empty
+// account is the account of a mint or a burn.
+func transferAccount(item datatype.StackItem) ([]byte, bool) {
+	if _, ok := item.(*datatype.ByteArray); !ok {
+		return nil, false
+	}
+	data := item.GetByteArray()
+	return data, len(data) == 0 || states.NEP5Address(data) != nil
+}
+
+// transferAmount returns the non-negative amount of a transfer notification.
+func transferAmount(item datatype.StackItem) (*big.Int, bool) {
+	var amount *big.Int
+	switch item := item.(type) {
+	case *datatype.Integer:
+		amount = item.GetBigInteger()
+	case *datatype.ByteArray:
+		data := item.GetByteArray()
+		if len(data) > maxNEP5AmountSize {
+			return nil, false
+		}
+		amount = service.BytesToBigInt(data)
+	default:
+		return nil, false
+	}
+	return amount, amount.Sign() >= 0
+}
+
+// nep5Transfer returns the transfer raised by a notification, it returns nil
+// if the notification is not a NEP-5 transfer. A transfer is an array of the
+// "transfer" name, the from and the to accounts and a non-negative amount.
+func nep5Transfer(args *service.NotifyEventArgs) *states.NEP5Transfer {
+	if args.EventName != "transfer" {
+		return nil
+	}
+	items := args.Item.GetArray()
+	if len(items) != 4 {
+		return nil
+	}
+	from, ok := transferAccount(items[1])
+	if !ok {
+		return nil
+	}
+	to, ok := transferAccount(items[2])
+	if !ok || len(from) == 0 && len(to) == 0 {
+		return nil
+	}
+	amount, ok := transferAmount(items[3])
+	if !ok {
+		return nil
+	}
+	return &states.NEP5Transfer{
+		AssetHash: args.ScriptHash,
+		From:      from,
+		To:        to,
+		Amount:    amount,
+		TxID:      args.TxID,
+		Height:    args.Height,
+		Index:     uint32(args.Index),
+	}
+}
+
+// addNEP5Transfers records the NEP-5 transfers raised by a persisted
+// execution and applies them to the balances of the accounts.
+func (c *LedgerStore) addNEP5Transfers(state *blockState, block *side.Block, stateMachine *service.StateMachine) error {
+	getBalance := func(address []byte, assetHash *common.Uint168) (*states.NEP5Balance, error) {
+		key := string(states.NEP5BalanceKey(address, assetHash))
+		if balance, ok := state.balances[key]; ok {
+			return balance, nil
+		}
+		balance, err := c.GetNEP5Balance(address, assetHash)
+		if err != nil {
+			if err.Error() != ErrDBNotFound.Error() {
+				return nil, err
+			}
+			balance = &states.NEP5Balance{Balance: new(big.Int)}
+		}
+		state.balances[key] = balance
+		return balance, nil
+	}
+	update := func(account []byte, assetHash *common.Uint168, amount *big.Int) error {
+		if len(account) == 0 {
+			return nil
+		}
+		balance, err := getBalance(states.NEP5Address(account), assetHash)
+		if err != nil {
+			return err
+		}
+		balance.Balance = new(big.Int).Add(balance.Balance, amount)
+		balance.LastUpdatedHeight = block.Height
+		return nil
+	}
+
+	for _, args := range stateMachine.NotifyEvents {
+		transfer := nep5Transfer(args)
+		if transfer == nil {
+			continue
+		}
+		transfer.Timestamp = block.Timestamp
+		if err := update(transfer.From, &transfer.AssetHash, new(big.Int).Neg(transfer.Amount)); err != nil {
+			return err
+		}
+		if err := update(transfer.To, &transfer.AssetHash, transfer.Amount); err != nil {
+			return err
+		}
+		state.transfers = append(state.transfers, transfer)
+	}
+	return nil
+}
+
+// persistNEP5Transfers writes the NEP-5 balances changed by a block and the
+// transfers of the block under both of their accounts.
+func persistNEP5Transfers(batch database.Batch, state *blockState, height uint32) error {
+	keys := make([]string, 0, len(state.balances))
+	for key := range state.balances {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := batch.Put([]byte(key), state.balances[key].Bytes()); err != nil {
+			return err
+		}
+	}
+	for seq, transfer := range state.transfers {
+		from := states.NEP5Address(transfer.From)
+		to := states.NEP5Address(transfer.To)
+		if from != nil {
+			key := states.NEP5TransferKey(from, height, uint32(seq))
+			if err := batch.Put(key, transfer.Bytes()); err != nil {
+				return err
+			}
+		}
+		if to != nil && !bytes.Equal(from, to) {
+			key := states.NEP5TransferKey(to, height, uint32(seq))
+			if err := batch.Put(key, transfer.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+// emitTransfer emits a transfer notification, an empty account mints or
+// burns the amount.
+func emitTransfer(builder *avm.ParamsBuilder, from, to []byte, amount int64) {
+	builder.EmitPushInteger(amount)
+	builder.EmitPushByteArray(to)
+	builder.EmitPushByteArray(from)
+	builder.EmitPushByteArray([]byte("transfer"))
+	builder.EmitPushInteger(4)
+	builder.Emit(avm.PACK)
+	builder.EmitSysCall("Neo.Runtime.Notify")
+}
+
+func transferScript(from, to []byte, amount int64) []byte {
+	builder := scriptBuilder()
+	emitTransfer(builder, from, to, amount)
+	return builder.Bytes()
+}
+
+func TestPersistNEP5Transfers(t *testing.T) {
+	alice := bytes.Repeat([]byte{1}, states.NEP5AddressSize)
+	bob := bytes.Repeat([]byte{2}, states.NEP5AddressSize)
+	c, db := newTestLedgerStore()
+	deploy, codeHash := deployTx(contractScript(
+		operationScript("mint", transferScript(nil, alice, 100)),
+		operationScript("transfer", transferScript(alice, bob, 30)),
+		// not transfers: a negative amount and a transfer between no accounts
+		operationScript("invalid", append(transferScript(alice, bob, -1), transferScript(nil, nil, 1)...)),
+	))
+	_, err := persistBlock(c, 1, deploy)
+	assert.NoError(t, err)
+	balance := func(address []byte) int64 {
+		balance, err := c.GetNEP5Balance(address, codeHash)
+		if err != nil {
+			return -1
+		}
+		return balance.Balance.Int64()
+	}
+
+	_, err = persistBlock(c, 2, invokeTx(codeHash, 1, "mint"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), balance(alice))
+	assert.Equal(t, int64(-1), balance(bob))
+
+	before := db.snapshot()
+	invoke := invokeTx(codeHash, 1, "transfer")
+	block, err := persistBlock(c, 3, invoke, invokeTx(codeHash, 1, "invalid"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(70), balance(alice))
+	assert.Equal(t, int64(30), balance(bob))
+	for _, address := range [][]byte{alice, bob} {
+		data, ok := db.data[string(states.NEP5TransferKey(address, 3, 0))]
+		assert.True(t, ok)
+		transfer := new(states.NEP5Transfer)
+		assert.NoError(t, transfer.Deserialize(bytes.NewReader(data)))
+		assert.Equal(t, int64(30), transfer.Amount.Int64())
+		assert.Equal(t, invoke.Hash(), transfer.TxID)
+		_, ok = db.data[string(states.NEP5TransferKey(address, 3, 1))]
+		assert.False(t, ok)
+	}
+
+	// a rollback restores the balances and removes the transfers
+	assert.NoError(t, rollbackBlock(c, block))
+	assert.Equal(t, before, db.snapshot())
+	assert.Equal(t, int64(100), balance(alice))
+	assert.Equal(t, int64(-1), balance(bob))
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+// ReindexContractsFlag is present while the contract state is being rebuilt,
+// so an interrupted reindex is detected on the next start.
+const ReindexContractsFlag = "ReindexContractsFlag"
+
+// reindexPrefixes are the entries rebuilt by re-executing the blocks.
+var reindexPrefixes = []sb.EntryPrefix{
+	sb.ST_Contract,
+	sb.ST_Storage,
+	sb.ST_AssetState,
+	sb.ST_Account,
+	states.IX_Contract,
+	states.ST_ApplicationLog,
+	states.SYS_ContractState,
+	states.ST_StateRoot,
+	states.ST_TrieNode,
+	states.ST_UndoJournal,
+	states.IX_ContractEvent,
+	states.ST_EventBloom,
+	states.ST_NEP5Balance,
+	states.IX_NEP5Transfer,
+	states.ST_NEP11Balance,
+	states.IX_NEP11Owner,
+	states.IX_NEP11Transfer,
+	states.ST_ContractManifest,
+}
+
+type ReindexConfig struct {
+	// Reference holds the state roots the blocks are expected to have, the
+	// reindex stops at the first block whose root is different.
+	Reference map[uint32]common.Uint256
+	// Progress is called after every reindexed block.
+	Progress func(height, bestHeight uint32)
+}
+
+// ReindexContracts wipes the contract and account state and rebuilds it by
+// executing the transactions of every block from the genesis block.
+func (c *LedgerStore) ReindexContracts(cfg *ReindexConfig) error {
+	if err := c.Put([]byte(ReindexContractsFlag), []byte{1}); err != nil {
+		return err
+	}
+	if err := c.wipeContractState(); err != nil {
+		return err
+	}
+
+	bestHeight := c.GetHeight()
+	for height := uint32(0); height <= bestHeight; height++ {
+		hash, err := c.GetBlockHash(height)
+		if err != nil {
+			return err
+		}
+		block, err := c.GetBlock(hash)
+		if err != nil {
+			return err
+		}
+		batch := c.NewBatch()
+		if err := c.PersisAccount(batch, block); err != nil {
+			return err
+		}
+		// the genesis block is not persisted through persistTransactions,
+		// so it has no contract state either.
+		if height > 0 {
+			if err := c.persistContracts(batch, block); err != nil {
+				return err
+			}
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+		if err := c.checkReferenceRoot(cfg.Reference, height); err != nil {
+			return err
+		}
+		if cfg.Progress != nil {
+			cfg.Progress(height, bestHeight)
+		}
+	}
+
+	if err := c.Put([]byte(AccountPersisFlag), []byte{1}); err != nil {
+		return err
+	}
+	if err := c.Put([]byte(StorageTrieFlag), []byte{1}); err != nil {
+		return err
+	}
+	return c.Delete([]byte(ReindexContractsFlag))
+}
+
+func (c *LedgerStore) wipeContractState() error {
+	for _, prefix := range reindexPrefixes {
+		batch := c.NewBatch()
+		iter := c.NewIterator([]byte{byte(prefix)})
+		for iter.Next() {
+			batch.Delete(append([]byte{}, iter.Key()...))
+		}
+		iter.Release()
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *LedgerStore) checkReferenceRoot(reference map[uint32]common.Uint256, height uint32) error {
+	expected, ok := reference[height]
+	if !ok {
+		return nil
+	}
+	root, err := c.GetStateRoot(height)
+	if err != nil {
+		return fmt.Errorf("state root of block %d not found, %s", height, err)
+	}
+	if !root.Root.IsEqual(expected) {
+		return fmt.Errorf("state root of block %d is %s, the reference is %s",
+			height, root.Root.String(), expected.String())
+	}
+	return nil
+}
+
+func (c *LedgerStore) checkReindexFlag() error {
+	if _, err := c.Get([]byte(ReindexContractsFlag)); err == nil {
+		return errors.New("the last contract reindex was interrupted, reindex the contracts again")
+	}
+	return nil
+}
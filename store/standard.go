@@ -0,0 +1,177 @@
+package store
+
+import (
+	"bytes"
+	"math/big"
+
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm/datatype"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/blockchain"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/smartcontract/service"
+)
+
+// standardProbeGas is the max gas a probe of a read-only method may consume.
+const standardProbeGas common.Fixed64 = 10 * 100000000
+
+// contractStandard is what a contract implementing a standard looks like.
+// Compiled contracts dispatch on the method name, so the names of the methods
+// are pushed by the code, and the syscalls are called by the code.
+type contractStandard struct {
+	name     string
+	methods  []string
+	syscalls []string
+	// probes are the read-only methods invoked to confirm the standard.
+	probes []string
+}
+
+var contractStandards = []*contractStandard{
+	{
+		name:     states.StandardNEP5,
+		methods:  []string{"name", "symbol", "decimals", "totalSupply", "balanceOf", "transfer"},
+		syscalls: []string{"Neo.Runtime.Notify", "Neo.Runtime.CheckWitness", "Neo.Storage.Get", "Neo.Storage.Put"},
+		probes:   []string{"name", "symbol", "decimals", "totalSupply"},
+	},
+	{
+		name: states.StandardNEP11,
+		methods: []string{"symbol", "decimals", "totalSupply", "balanceOf", "ownerOf", "tokensOf",
+			"transfer", "properties"},
+		syscalls: []string{"Neo.Runtime.Notify", "Neo.Runtime.CheckWitness", "Neo.Storage.Get", "Neo.Storage.Put"},
+		probes:   []string{"symbol", "decimals", "totalSupply"},
+	},
+}
+
+// codePushes returns whether the code pushes data with a PUSHBYTES opcode.
+func codePushes(code []byte, data string) bool {
+	return len(data) > 0 && len(data) <= int(avm.PUSHBYTES75) &&
+		bytes.Contains(code, append([]byte{byte(len(data))}, data...))
+}
+
+// codeCalls returns whether the code calls the syscall.
+func codeCalls(code []byte, syscall string) bool {
+	return bytes.Contains(code, append([]byte{byte(avm.SYSCALL), byte(len(syscall))}, syscall...))
+}
+
+func (standard *contractStandard) matchCode(code []byte) bool {
+	for _, method := range standard.methods {
+		if !codePushes(code, method) {
+			return false
+		}
+	}
+	for _, syscall := range standard.syscalls {
+		if !codeCalls(code, syscall) {
+			return false
+		}
+	}
+	return true
+}
+
+// probeResultValid returns whether the result of a probed method is what the
+// standards expect: a name or a symbol is a non-empty string, the decimals are
+// an integer in [0, 255] and the total supply a non-negative integer.
+func probeResultValid(method string, item datatype.StackItem) bool {
+	integer := func() *big.Int {
+		switch item.(type) {
+		case *datatype.Integer:
+			return item.GetBigInteger()
+		case *datatype.ByteArray:
+			return service.BytesToBigInt(item.GetByteArray())
+		}
+		return nil
+	}
+	switch method {
+	case "name", "symbol":
+		_, ok := item.(*datatype.ByteArray)
+		return ok && len(item.GetByteArray()) > 0
+	case "decimals":
+		value := integer()
+		return value != nil && value.Sign() >= 0 && value.Cmp(big.NewInt(255)) <= 0
+	case "totalSupply":
+		value := integer()
+		return value != nil && value.Sign() >= 0
+	}
+	return false
+}
+
+// detectStandards returns the standards a contract created by tx appears to
+// implement. The code is checked for the methods and the syscalls of each
+// standard, then the read-only methods are invoked against dbCache without
+// changing it. The probes consume the gas tx left and gas is decreased by what
+// they use, a probe that runs out of it fails, so detecting the standards never
+// costs more than the sender paid for.
+func (c *LedgerStore) detectStandards(block *side.Block, tx *side.Transaction, dbCache *blockchain.DBCache,
+	codeHash *common.Uint168, code []byte, gas *common.Fixed64) []string {
+	results := make(map[string]bool)
+	probe := func(method string) bool {
+		if valid, ok := results[method]; ok {
+			return valid
+		}
+		results[method] = false
+		probeGas := *gas
+		if probeGas > standardProbeGas {
+			probeGas = standardProbeGas
+		}
+		if probeGas <= 0 {
+			return false
+		}
+		probeCache := blockchain.NewChildDBCache(dbCache)
+		stateMachine := service.NewStateMachine(probeCache, probeCache)
+		stateMachine.Environment = service.NewExecutionEnvironment(block, tx)
+		buffer := new(bytes.Buffer)
+		builder := avm.NewParamsBuider(buffer)
+		builder.EmitPushInteger(0)
+		builder.Emit(avm.PACK)
+		builder.EmitPushByteArray([]byte(method))
+		builder.EmitPushCall(common.BytesReverse(params.UInt168ToUInt160(codeHash)))
+		contract, err := smartcontract.NewSmartContract(&smartcontract.Context{
+			StateMachine:   *stateMachine,
+			CodeHash:       *codeHash,
+			Input:          buffer.Bytes(),
+			SignableData:   tx,
+			CacheCodeTable: NewCacheCodeTable(probeCache),
+			Time:           big.NewInt(int64(block.Timestamp)),
+			BlockNumber:    big.NewInt(int64(block.Height)),
+			// the engine gives the free gas on top of the gas it is created with
+			Gas:     probeGas - avm.FreeGas,
+			Trigger: avm.Application,
+		})
+		if err != nil {
+			return false
+		}
+		err = contract.Execute()
+		engine := contract.Engine.(*avm.ExecutionEngine)
+		*gas -= common.Fixed64(engine.GetGasConsumed())
+		if err != nil {
+			return false
+		}
+		if engine.GetEvaluationStack().Count() == 0 {
+			return false
+		}
+		results[method] = probeResultValid(method, avm.PopStackItem(engine))
+		return results[method]
+	}
+
+	var standards []string
+	for _, standard := range contractStandards {
+		if !standard.matchCode(code) {
+			continue
+		}
+		valid := true
+		for _, method := range standard.probes {
+			if !probe(method) {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			standards = append(standards, standard.name)
+		}
+	}
+	return standards
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/types"
+)
+
+func pushScript(value string) []byte {
+	builder := scriptBuilder()
+	builder.EmitPushByteArray([]byte(value))
+	return builder.Bytes()
+}
+
+// nep5Script returns the code of a contract answering the probes of the NEP-5
+// standard, name runs the given body.
+func nep5Script(name []byte) []byte {
+	code := contractScript(
+		operationScript("name", name),
+		operationScript("symbol", pushScript("TKN")),
+		operationScript("decimals", []byte{byte(avm.PUSH8)}),
+		operationScript("totalSupply", []byte{byte(avm.PUSH1)}),
+	)
+	// the rest of the methods and the syscalls are never reached
+	builder := scriptBuilder()
+	builder.EmitPushByteArray([]byte("balanceOf"))
+	builder.EmitPushByteArray([]byte("transfer"))
+	for _, syscall := range contractStandards[0].syscalls {
+		builder.EmitSysCall(syscall)
+	}
+	return append(code, builder.Bytes()...)
+}
+
+func TestDetectStandards(t *testing.T) {
+	c, _ := newTestLedgerStore()
+	deploy, codeHash := deployTx(nep5Script(pushScript("Token")))
+	_, err := persistBlock(c, 1, deploy)
+	assert.NoError(t, err)
+	index, err := c.GetContractIndex(codeHash)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{states.StandardNEP5}, index.Standards)
+
+	// a probe never returning stops once the gas left by the deploy is used up
+	deploy, codeHash = deployTx(nep5Script([]byte{byte(avm.JMP), 0, 0}))
+	_, err = persistBlock(c, 2, deploy)
+	assert.NoError(t, err)
+	index, err = c.GetContractIndex(codeHash)
+	assert.NoError(t, err)
+	assert.Empty(t, index.Standards)
+
+	// the standards of a contract created by an invoke are detected as well
+	deploy, codeHash = deployTx(nep5Script(pushScript("Other")))
+	invoke := &side.Transaction{
+		TxType: side.Invoke,
+		Payload: &types.PayloadInvoke{
+			Code: deploy.Payload.(*types.PayloadDeploy).CreateScript(),
+			Gas:  500 * 100000000,
+		},
+	}
+	_, err = persistBlock(c, 3, invoke)
+	assert.NoError(t, err)
+	index, err = c.GetContractIndex(codeHash)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{states.StandardNEP5}, index.Standards)
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/elastos/Elastos.ELA.SideChain/database"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+)
+
+type getter interface {
+	Get(key []byte) ([]byte, error)
+}
+
+// journalBatch records the value every key had before it is first written
+// through the batch, the recorded values form the undo journal of a block.
+type journalBatch struct {
+	database.Batch
+	db      getter
+	seen    map[string]bool
+	entries []states.UndoEntry
+}
+
+func newJournalBatch(batch database.Batch, db getter) *journalBatch {
+	return &journalBatch{
+		Batch: batch,
+		db:    db,
+		seen:  make(map[string]bool),
+	}
+}
+
+func (b *journalBatch) record(key []byte) error {
+	if b.seen[string(key)] {
+		return nil
+	}
+	entry := states.UndoEntry{Key: append([]byte{}, key...)}
+	value, err := b.db.Get(key)
+	if err != nil && err.Error() != ErrDBNotFound.Error() {
+		return err
+	}
+	if err == nil {
+		entry.Exists = true
+		entry.Value = value
+	}
+	b.seen[string(key)] = true
+	b.entries = append(b.entries, entry)
+	return nil
+}
+
+func (b *journalBatch) Put(key []byte, value []byte) error {
+	if err := b.record(key); err != nil {
+		return err
+	}
+	return b.Batch.Put(key, value)
+}
+
+func (b *journalBatch) Delete(key []byte) error {
+	if err := b.record(key); err != nil {
+		return err
+	}
+	return b.Batch.Delete(key)
+}
+
+// commit writes the undo journal of block into the underlying batch.
+func (b *journalBatch) commit(block *side.Block) error {
+	journal := &states.UndoJournal{
+		Height:    block.Height,
+		BlockHash: block.Hash(),
+		Entries:   b.entries,
+	}
+	return b.Batch.Put(states.UndoJournalKey(block.Height), journal.Bytes())
+}
+
+func (c *LedgerStore) GetUndoJournal(height uint32) (*states.UndoJournal, error) {
+	data, err := c.Get(states.UndoJournalKey(height))
+	if err != nil {
+		return nil, err
+	}
+	journal := new(states.UndoJournal)
+	if err := journal.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// rollbackContractState restores the contract state overwritten by a block
+// which is disconnected from the chain.
+func (c *LedgerStore) rollbackContractState(batch database.Batch, b *side.Block) error {
+	journal, err := c.GetUndoJournal(b.Height)
+	if err != nil {
+		if err.Error() != ErrDBNotFound.Error() {
+			return err
+		}
+		log.Warnf("undo journal of block %d not found, its contract state is not rolled back", b.Height)
+		return nil
+	}
+	hash := b.Hash()
+	if !journal.BlockHash.IsEqual(hash) {
+		return errors.New("undo journal does not belong to block " + hash.String())
+	}
+	if err := applyUndoJournal(batch, journal); err != nil {
+		return err
+	}
+	return batch.Delete(states.UndoJournalKey(b.Height))
+}
+
+func applyUndoJournal(batch database.Batch, journal *states.UndoJournal) error {
+	for _, entry := range journal.Entries {
+		var err error
+		if entry.Exists {
+			err = batch.Put(entry.Key, entry.Value)
+		} else {
+			err = batch.Delete(entry.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sb "github.com/elastos/Elastos.ELA.SideChain/blockchain"
+	side "github.com/elastos/Elastos.ELA.SideChain/types"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract/states"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/params"
+)
+
+func contractKey(codeHash common.Uint168) []byte {
+	return append([]byte{byte(sb.ST_Contract)}, params.UInt168ToUInt160(&codeHash)...)
+}
+
+func storageKey(codeHash common.Uint168, key string) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(sb.ST_Storage))
+	states.NewStorageKey(&codeHash, []byte(key)).Serialize(buf)
+	return buf.Bytes()
+}
+
+func migrateScript(code []byte) []byte {
+	builder := scriptBuilder()
+	for _, field := range []string{"description", "email", "author", "version", "name"} {
+		builder.EmitPushByteArray([]byte(field))
+	}
+	builder.EmitPushBool(true)
+	builder.EmitPushInteger(int64(contract.Void))
+	builder.EmitPushByteArray([]byte{})
+	builder.EmitPushByteArray(code)
+	builder.EmitSysCall("Neo.Contract.Migrate")
+	return builder.Bytes()
+}
+
+func TestRollbackContractState(t *testing.T) {
+	builder := scriptBuilder()
+	builder.EmitSysCall("Neo.Contract.Destroy")
+	codeB := contractScript(operationScript("destroy", builder.Bytes()))
+
+	put := scriptBuilder()
+	emitStoragePut(put, "k1", "v1")
+	emitStoragePut(put, "k2", "v2")
+	update := scriptBuilder()
+	emitStoragePut(update, "k1", "v3")
+	update.EmitPushByteArray([]byte("k2"))
+	update.EmitSysCall("Neo.Storage.GetContext")
+	update.EmitSysCall("Neo.Storage.Delete")
+	codeA := contractScript(
+		operationScript("put", put.Bytes()),
+		operationScript("update", update.Bytes()),
+		operationScript("migrate", migrateScript(codeB)),
+	)
+	deploy, contractA := deployTx(codeA)
+	contractB, _ := params.ToCodeHash(codeB)
+
+	c, db := newTestLedgerStore()
+	txs := [][]*side.Transaction{
+		{deploy},
+		{invokeTx(contractA, 1, "put")},
+		{invokeTx(contractA, 2, "update")},
+		{invokeTx(contractA, 3, "migrate")},
+		{invokeTx(contractB, 4, "destroy")},
+	}
+	var snapshots []map[string][]byte
+	var blocks []*side.Block
+	for i, blockTxs := range txs {
+		snapshots = append(snapshots, db.snapshot())
+		block, err := persistBlock(c, uint32(i+1), blockTxs...)
+		assert.NoError(t, err)
+		blocks = append(blocks, block)
+
+		switch i {
+		case 2:
+			assert.Equal(t, []byte("v3"), mustStorageItem(t, db, storageKey(*contractA, "k1")).Value)
+			_, ok := db.data[string(storageKey(*contractA, "k2"))]
+			assert.False(t, ok)
+		case 3:
+			_, ok := db.data[string(contractKey(*contractA))]
+			assert.False(t, ok)
+			_, ok = db.data[string(contractKey(*contractB))]
+			assert.True(t, ok)
+			_, ok = db.data[string(storageKey(*contractB, "k1"))]
+			assert.True(t, ok)
+		case 4:
+			_, ok := db.data[string(contractKey(*contractB))]
+			assert.False(t, ok)
+		}
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		assert.NoError(t, rollbackBlock(c, blocks[i]))
+		assert.Equal(t, snapshots[i], db.data)
+	}
+	assert.Equal(t, 0, len(db.data))
+}
+
+func mustStorageItem(t *testing.T, db *memDB, key []byte) *states.StorageItem {
+	item := new(states.StorageItem)
+	assert.NoError(t, item.Deserialize(bytes.NewReader(db.data[string(key)])))
+	return item
+}
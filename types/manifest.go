@@ -0,0 +1,328 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
+)
+
+const (
+	// MaxManifestSize is the max size of a serialized manifest.
+	MaxManifestSize = 64 * 1024
+
+	// MaxManifestNameLength is the max length of the name of a method, an
+	// event, a parameter or a standard.
+	MaxManifestNameLength = 32
+
+	MaxManifestMethods     = 256
+	MaxManifestEvents      = 256
+	MaxManifestParameters  = 16
+	MaxManifestStandards   = 16
+	MaxManifestPermissions = 64
+)
+
+// ManifestParameter is a named parameter of a method or an event.
+type ManifestParameter struct {
+	Name string
+	Type contract.ContractParameterType
+}
+
+// ManifestMethod is an operation of the contract, the parameters are the
+// elements of the arguments array the contract is invoked with.
+type ManifestMethod struct {
+	Name       string
+	Parameters []ManifestParameter
+	ReturnType contract.ContractParameterType
+}
+
+// ManifestEvent is a notification the contract raises, the parameters are the
+// elements following the event name.
+type ManifestEvent struct {
+	Name       string
+	Parameters []ManifestParameter
+}
+
+// ManifestPermission declares the methods of a contract the contract calls,
+// a zero Contract is any contract and no Methods are all the methods.
+type ManifestPermission struct {
+	Contract common.Uint168
+	Methods  []string
+}
+
+// ContractManifest describes the interface of a contract. It is optional and
+// only used by clients, the execution of the contract does not depend on it.
+type ContractManifest struct {
+	Methods     []ManifestMethod
+	Events      []ManifestEvent
+	Standards   []string
+	Permissions []ManifestPermission
+}
+
+// Method returns the method with name, or nil if the manifest has none.
+func (m *ContractManifest) Method(name string) *ManifestMethod {
+	for i := range m.Methods {
+		if m.Methods[i].Name == name {
+			return &m.Methods[i]
+		}
+	}
+	return nil
+}
+
+func (m *ContractManifest) Serialize(w io.Writer) error {
+	if err := common.WriteVarUint(w, uint64(len(m.Methods))); err != nil {
+		return err
+	}
+	for _, method := range m.Methods {
+		if err := common.WriteVarString(w, method.Name); err != nil {
+			return err
+		}
+		if err := serializeManifestParameters(w, method.Parameters); err != nil {
+			return err
+		}
+		if err := common.WriteUint8(w, uint8(method.ReturnType)); err != nil {
+			return err
+		}
+	}
+	if err := common.WriteVarUint(w, uint64(len(m.Events))); err != nil {
+		return err
+	}
+	for _, event := range m.Events {
+		if err := common.WriteVarString(w, event.Name); err != nil {
+			return err
+		}
+		if err := serializeManifestParameters(w, event.Parameters); err != nil {
+			return err
+		}
+	}
+	if err := serializeManifestNames(w, m.Standards); err != nil {
+		return err
+	}
+	if err := common.WriteVarUint(w, uint64(len(m.Permissions))); err != nil {
+		return err
+	}
+	for _, permission := range m.Permissions {
+		if err := permission.Contract.Serialize(w); err != nil {
+			return err
+		}
+		if err := serializeManifestNames(w, permission.Methods); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ContractManifest) Deserialize(r io.Reader) error {
+	count, err := readManifestCount(r, MaxManifestMethods)
+	if err != nil {
+		return err
+	}
+	m.Methods = make([]ManifestMethod, count)
+	for i := range m.Methods {
+		method := &m.Methods[i]
+		if method.Name, err = common.ReadVarString(r); err != nil {
+			return err
+		}
+		if method.Parameters, err = deserializeManifestParameters(r); err != nil {
+			return err
+		}
+		returnType, err := common.ReadUint8(r)
+		if err != nil {
+			return err
+		}
+		method.ReturnType = contract.ContractParameterType(returnType)
+	}
+	if count, err = readManifestCount(r, MaxManifestEvents); err != nil {
+		return err
+	}
+	m.Events = make([]ManifestEvent, count)
+	for i := range m.Events {
+		event := &m.Events[i]
+		if event.Name, err = common.ReadVarString(r); err != nil {
+			return err
+		}
+		if event.Parameters, err = deserializeManifestParameters(r); err != nil {
+			return err
+		}
+	}
+	if m.Standards, err = deserializeManifestNames(r, MaxManifestStandards); err != nil {
+		return err
+	}
+	if count, err = readManifestCount(r, MaxManifestPermissions); err != nil {
+		return err
+	}
+	m.Permissions = make([]ManifestPermission, count)
+	for i := range m.Permissions {
+		permission := &m.Permissions[i]
+		if err := permission.Contract.Deserialize(r); err != nil {
+			return err
+		}
+		if permission.Methods, err = deserializeManifestNames(r, MaxManifestMethods); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ContractManifest) Bytes() []byte {
+	b := new(bytes.Buffer)
+	m.Serialize(b)
+	return b.Bytes()
+}
+
+// Validate checks the names are not empty, not too long and unique, the
+// parameter types are known and the manifest is not too large.
+func (m *ContractManifest) Validate() error {
+	if len(m.Methods) > MaxManifestMethods || len(m.Events) > MaxManifestEvents ||
+		len(m.Standards) > MaxManifestStandards || len(m.Permissions) > MaxManifestPermissions {
+		return errors.New("manifest has too many entries")
+	}
+	if len(m.Bytes()) > MaxManifestSize {
+		return errors.New("manifest is too large")
+	}
+	methods := make(map[string]struct{})
+	for _, method := range m.Methods {
+		if err := checkManifestName("method", method.Name, methods); err != nil {
+			return err
+		}
+		if err := checkManifestParameters(method.Name, method.Parameters); err != nil {
+			return err
+		}
+		if method.ReturnType != contract.Void && !isManifestParameterType(method.ReturnType) {
+			return fmt.Errorf("method %s has an unknown return type", method.Name)
+		}
+	}
+	events := make(map[string]struct{})
+	for _, event := range m.Events {
+		if err := checkManifestName("event", event.Name, events); err != nil {
+			return err
+		}
+		if err := checkManifestParameters(event.Name, event.Parameters); err != nil {
+			return err
+		}
+	}
+	standards := make(map[string]struct{})
+	for _, standard := range m.Standards {
+		if err := checkManifestName("standard", standard, standards); err != nil {
+			return err
+		}
+	}
+	for _, permission := range m.Permissions {
+		if len(permission.Methods) > MaxManifestMethods {
+			return errors.New("permission has too many methods")
+		}
+		names := make(map[string]struct{})
+		for _, method := range permission.Methods {
+			if err := checkManifestName("permission method", method, names); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isManifestParameterType(t contract.ContractParameterType) bool {
+	return t != contract.Void && t.String() != "Unknown"
+}
+
+func checkManifestName(kind string, name string, names map[string]struct{}) error {
+	if len(name) == 0 || len(name) > MaxManifestNameLength {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	if _, ok := names[name]; ok {
+		return fmt.Errorf("duplicated %s %s", kind, name)
+	}
+	names[name] = struct{}{}
+	return nil
+}
+
+func checkManifestParameters(owner string, parameters []ManifestParameter) error {
+	if len(parameters) > MaxManifestParameters {
+		return fmt.Errorf("%s has too many parameters", owner)
+	}
+	names := make(map[string]struct{})
+	for _, parameter := range parameters {
+		if err := checkManifestName("parameter", parameter.Name, names); err != nil {
+			return fmt.Errorf("%s: %s", owner, err)
+		}
+		if !isManifestParameterType(parameter.Type) {
+			return fmt.Errorf("%s: parameter %s has an unknown type", owner, parameter.Name)
+		}
+	}
+	return nil
+}
+
+func readManifestCount(r io.Reader, max int) (int, error) {
+	count, err := common.ReadVarUint(r, 0)
+	if err != nil {
+		return 0, err
+	}
+	if count > uint64(max) {
+		return 0, errors.New("manifest has too many entries")
+	}
+	return int(count), nil
+}
+
+func serializeManifestParameters(w io.Writer, parameters []ManifestParameter) error {
+	if err := common.WriteVarUint(w, uint64(len(parameters))); err != nil {
+		return err
+	}
+	for _, parameter := range parameters {
+		if err := common.WriteVarString(w, parameter.Name); err != nil {
+			return err
+		}
+		if err := common.WriteUint8(w, uint8(parameter.Type)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deserializeManifestParameters(r io.Reader) ([]ManifestParameter, error) {
+	count, err := readManifestCount(r, MaxManifestParameters)
+	if err != nil {
+		return nil, err
+	}
+	parameters := make([]ManifestParameter, count)
+	for i := range parameters {
+		if parameters[i].Name, err = common.ReadVarString(r); err != nil {
+			return nil, err
+		}
+		t, err := common.ReadUint8(r)
+		if err != nil {
+			return nil, err
+		}
+		parameters[i].Type = contract.ContractParameterType(t)
+	}
+	return parameters, nil
+}
+
+func serializeManifestNames(w io.Writer, names []string) error {
+	if err := common.WriteVarUint(w, uint64(len(names))); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := common.WriteVarString(w, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deserializeManifestNames(r io.Reader, max int) ([]string, error) {
+	count, err := readManifestCount(r, max)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, count)
+	for i := range names {
+		if names[i], err = common.ReadVarString(r); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
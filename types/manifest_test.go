@@ -0,0 +1,91 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
+)
+
+func newTestManifest() *ContractManifest {
+	return &ContractManifest{
+		Methods: []ManifestMethod{
+			{
+				Name: "balanceOf",
+				Parameters: []ManifestParameter{
+					{Name: "account", Type: contract.Hash160},
+				},
+				ReturnType: contract.Integer,
+			},
+			{Name: "name", ReturnType: contract.String},
+		},
+		Events: []ManifestEvent{
+			{
+				Name: "transfer",
+				Parameters: []ManifestParameter{
+					{Name: "from", Type: contract.Hash160},
+					{Name: "to", Type: contract.Hash160},
+					{Name: "amount", Type: contract.Integer},
+				},
+			},
+		},
+		Standards: []string{"NEP-5"},
+		Permissions: []ManifestPermission{
+			{Contract: common.Uint168{0x1c, 1, 2}, Methods: []string{"transfer"}},
+			{},
+		},
+	}
+}
+
+func TestContractManifest_Serialize(t *testing.T) {
+	manifest := newTestManifest()
+	assert.NoError(t, manifest.Validate())
+
+	manifest2 := new(ContractManifest)
+	assert.NoError(t, manifest2.Deserialize(bytes.NewReader(manifest.Bytes())))
+	assert.Equal(t, manifest.Bytes(), manifest2.Bytes())
+	assert.Equal(t, contract.Hash160, manifest2.Method("balanceOf").Parameters[0].Type)
+	assert.Equal(t, contract.String, manifest2.Method("name").ReturnType)
+	assert.Nil(t, manifest2.Method("transfer"))
+}
+
+func TestContractManifest_Validate(t *testing.T) {
+	manifest := newTestManifest()
+	manifest.Methods = append(manifest.Methods, ManifestMethod{Name: "name", ReturnType: contract.Void})
+	assert.Error(t, manifest.Validate())
+
+	manifest = newTestManifest()
+	manifest.Methods[0].Parameters[0].Type = contract.Void
+	assert.Error(t, manifest.Validate())
+
+	manifest = newTestManifest()
+	manifest.Events[0].Name = strings.Repeat("e", MaxManifestNameLength+1)
+	assert.Error(t, manifest.Validate())
+
+	manifest = newTestManifest()
+	manifest.Standards = append(manifest.Standards, "")
+	assert.Error(t, manifest.Validate())
+}
+
+func TestPayloadDeploy_Manifest(t *testing.T) {
+	code := FunctionCode{Code: []byte{1, 2, 3}, ReturnType: contract.Void}
+	payload := PayloadDeploy{Code: &code, Name: "name", Manifest: newTestManifest()}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, payload.Serialize(buf, DeployManifestPayloadVersion))
+	payload2 := PayloadDeploy{}
+	assert.NoError(t, payload2.Deserialize(bytes.NewReader(buf.Bytes()), DeployManifestPayloadVersion))
+	assert.Equal(t, payload.Manifest.Bytes(), payload2.Manifest.Bytes())
+
+	// the manifest is not part of the older versions.
+	buf = new(bytes.Buffer)
+	assert.NoError(t, payload.Serialize(buf, 0))
+	payload2 = PayloadDeploy{Manifest: newTestManifest()}
+	assert.NoError(t, payload2.Deserialize(bytes.NewReader(buf.Bytes()), 0))
+	assert.Nil(t, payload2.Manifest)
+}
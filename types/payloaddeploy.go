@@ -2,11 +2,20 @@ package types
 
 import (
 	"bytes"
+	"errors"
 	"io"
 
 	"github.com/elastos/Elastos.ELA.Utility/common"
+
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/avm"
+	"github.com/elastos/Elastos.ELA.SideChain.NeoVM/contract"
 )
 
+// DeployManifestPayloadVersion is the first version of the deploy payload
+// with a manifest, the manifest follows the gas. Version 1 is skipped as
+// deploy payloads without a manifest have been signed with it.
+const DeployManifestPayloadVersion byte = 0x02
+
 type PayloadDeploy struct {
 	Code        *FunctionCode
 	Name        string
@@ -16,6 +25,7 @@ type PayloadDeploy struct {
 	Description string
 	ProgramHash common.Uint168
 	Gas         common.Fixed64
+	Manifest    *ContractManifest
 }
 
 func (dc *PayloadDeploy) Data(version byte) []byte  {
@@ -27,6 +37,18 @@ func (dc *PayloadDeploy) Data(version byte) []byte  {
 	return buf.Bytes()
 }
 
+// CreateScript returns the script which creates the contract of the payload
+// with the Neo.Contract.Create syscall.
+func (dc *PayloadDeploy) CreateScript() []byte {
+	buffer := new(bytes.Buffer)
+	paramBuilder := avm.NewParamsBuider(buffer)
+	parameterTypes := contract.ContractParameterTypeToByte(dc.Code.ParameterTypes)
+	returnType := byte(dc.Code.ReturnType)
+	paramBuilder.EmitSysCall("Neo.Contract.Create", dc.Code.Code, parameterTypes, returnType, dc.Name,
+		dc.CodeVersion, dc.Author, dc.Email, dc.Description)
+	return paramBuilder.Bytes()
+}
+
 func (dc *PayloadDeploy) Serialize(w io.Writer, version byte) error {
 	err := dc.Code.Serialize(w)
 	if err != nil {
@@ -68,6 +90,17 @@ func (dc *PayloadDeploy) Serialize(w io.Writer, version byte) error {
 		return err
 	}
 
+	if version >= DeployManifestPayloadVersion {
+		var manifest []byte
+		if dc.Manifest != nil {
+			manifest = dc.Manifest.Bytes()
+		}
+		err = common.WriteVarBytes(w, manifest)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -116,5 +149,24 @@ func (dc *PayloadDeploy) Deserialize(r io.Reader, version byte) error {
 	}
 	dc.Gas = gas
 
+	dc.Manifest = nil
+	if version >= DeployManifestPayloadVersion {
+		data, err := common.ReadVarBytes(r, MaxManifestSize, "PayloadDeploy Deserialize Manifest")
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 {
+			manifest := new(ContractManifest)
+			reader := bytes.NewReader(data)
+			if err := manifest.Deserialize(reader); err != nil {
+				return err
+			}
+			if reader.Len() != 0 {
+				return errors.New("PayloadDeploy Deserialize Manifest has trailing data")
+			}
+			dc.Manifest = manifest
+		}
+	}
+
 	return nil
 }
\ No newline at end of file
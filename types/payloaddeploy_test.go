@@ -14,7 +14,7 @@ import (
 
 func TestPayloadDeploy_Serialize(t *testing.T) {
 	payload := PayloadDeploy{}
-	code := contract.FunctionCode{}
+	code := FunctionCode{}
 	code.Code = []byte{1, 2, 3, 4, 3, 1, 2, 3, 4, 3, 1, 2, 3, 4, 3, 1, 2, 3, 4, 3, 3}
 	code.ParameterTypes = []contract.ContractParameterType{contract.Signature}
 	code.ReturnType = contract.Boolean